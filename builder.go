@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo
+
+import (
+	"net/url"
+)
+
+// ImposterBuilder builds an Imposter value fluently, as an alternative to
+// an Imposter struct literal with nested Stub values.
+type ImposterBuilder struct {
+	imp Imposter
+}
+
+// NewImposter returns a new *ImposterBuilder of an Imposter listening
+// on the given port and protocol.
+func NewImposter(port int, proto string) *ImposterBuilder {
+	return &ImposterBuilder{
+		imp: Imposter{
+			Port:  port,
+			Proto: proto,
+		},
+	}
+}
+
+// WithName sets the name of the Imposter.
+func (b *ImposterBuilder) WithName(name string) *ImposterBuilder {
+	b.imp.Name = name
+	return b
+}
+
+// WithRecordRequests sets whether the Imposter records the requests it receives.
+func (b *ImposterBuilder) WithRecordRequests(record bool) *ImposterBuilder {
+	b.imp.RecordRequests = record
+	return b
+}
+
+// AddStub adds the given Stub to the end of the Imposter stubs.
+func (b *ImposterBuilder) AddStub(stub Stub) *ImposterBuilder {
+	b.imp.Stubs = append(b.imp.Stubs, stub)
+	return b
+}
+
+// Build returns the built Imposter. The builder may continue to be used
+// afterwards without modifying the returned value.
+func (b *ImposterBuilder) Build() Imposter {
+	imp := b.imp
+	if b.imp.Stubs != nil {
+		imp.Stubs = append([]Stub(nil), b.imp.Stubs...)
+	}
+	return imp
+}
+
+// StubBuilder builds a Stub value fluently, as an alternative to a Stub
+// struct literal; see ImposterBuilder.AddStub.
+type StubBuilder struct {
+	stub Stub
+}
+
+// NewStub returns a new *StubBuilder of a Stub without any Predicates
+// or Responses.
+func NewStub() *StubBuilder {
+	return &StubBuilder{}
+}
+
+// WithPredicate adds the given Predicate to the Stub predicates, which
+// are logically AND'd together.
+func (b *StubBuilder) WithPredicate(p Predicate) *StubBuilder {
+	b.stub.Predicates = append(b.stub.Predicates, p)
+	return b
+}
+
+// WithResponse adds the given Response to the end of the Stub responses.
+func (b *StubBuilder) WithResponse(r Response) *StubBuilder {
+	b.stub.Responses = append(b.stub.Responses, r)
+	return b
+}
+
+// WithResponses adds the given Responses to the end of the Stub responses
+// in order, such as those returned by StatusSequence.
+func (b *StubBuilder) WithResponses(rs ...Response) *StubBuilder {
+	b.stub.Responses = append(b.stub.Responses, rs...)
+	return b
+}
+
+// Build returns the built Stub. The builder may continue to be used
+// afterwards without modifying the returned value.
+func (b *StubBuilder) Build() Stub {
+	stub := b.stub
+	if b.stub.Predicates != nil {
+		stub.Predicates = append([]Predicate(nil), b.stub.Predicates...)
+	}
+	if b.stub.Responses != nil {
+		stub.Responses = append([]Response(nil), b.stub.Responses...)
+	}
+	return stub
+}
+
+// FormPredicate returns a Predicate of the given operator, such as PredicateEquals
+// or PredicateDeepEquals, matching the fields of an 'application/x-www-form-urlencoded'
+// request body as parsed by mountebank, rather than the raw body whose fields may be
+// encoded in any order. With PredicateEquals the request may contain other fields,
+// while with PredicateDeepEquals it must contain exactly the given fields.
+func FormPredicate(operator string, form url.Values) Predicate {
+	return Predicate{
+		Operator: operator,
+		Request: HTTPRequest{
+			Form: form,
+		},
+	}
+}
+
+// StatusSequence returns a Response of the "is" type for each of the given HTTP
+// status codes in order, such as 200, 200 and 500 for a Stub that fails every
+// third request.
+func StatusSequence(codes ...int) []Response {
+	rs := make([]Response, len(codes))
+	for i, code := range codes {
+		rs[i] = Response{
+			Type: "is",
+			Value: HTTPResponse{
+				StatusCode: code,
+			},
+		}
+	}
+	return rs
+}
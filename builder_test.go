@@ -0,0 +1,232 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ogbofjnr/mbgo"
+	"github.com/ogbofjnr/mbgo/internal/assert"
+)
+
+func TestImposterBuilder_Build(t *testing.T) {
+	cases := map[string]struct {
+		builder *mbgo.ImposterBuilder
+		want    mbgo.Imposter
+	}{
+		"builds an imposter without stubs": {
+			builder: mbgo.NewImposter(8080, "tcp").
+				WithName("builder_test"),
+			want: mbgo.Imposter{
+				Port:  8080,
+				Proto: "tcp",
+				Name:  "builder_test",
+			},
+		},
+		"builds an imposter with stubs": {
+			builder: mbgo.NewImposter(8080, "http").
+				WithName("builder_test").
+				WithRecordRequests(true).
+				AddStub(mbgo.NewStub().
+					WithPredicate(mbgo.Predicate{
+						Operator: mbgo.PredicateEquals,
+						Request: mbgo.HTTPRequest{
+							Method: http.MethodGet,
+						},
+					}).
+					WithPredicate(mbgo.Predicate{
+						Operator: mbgo.PredicateStartsWith,
+						Request: mbgo.HTTPRequest{
+							Path: "/users",
+						},
+					}).
+					WithResponse(mbgo.Response{
+						Type: "is",
+						Value: mbgo.HTTPResponse{
+							StatusCode: http.StatusOK,
+						},
+					}).
+					Build()).
+				AddStub(mbgo.NewStub().
+					WithResponse(mbgo.Response{
+						Type: "is",
+						Value: mbgo.HTTPResponse{
+							StatusCode: http.StatusNotFound,
+						},
+					}).
+					Build()),
+			want: mbgo.Imposter{
+				Port:           8080,
+				Proto:          "http",
+				Name:           "builder_test",
+				RecordRequests: true,
+				Stubs: []mbgo.Stub{
+					{
+						Predicates: []mbgo.Predicate{
+							{
+								Operator: mbgo.PredicateEquals,
+								Request: mbgo.HTTPRequest{
+									Method: http.MethodGet,
+								},
+							},
+							{
+								Operator: mbgo.PredicateStartsWith,
+								Request: mbgo.HTTPRequest{
+									Path: "/users",
+								},
+							},
+						},
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: mbgo.HTTPResponse{
+									StatusCode: http.StatusOK,
+								},
+							},
+						},
+					},
+					{
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: mbgo.HTTPResponse{
+									StatusCode: http.StatusNotFound,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := c.builder.Build()
+			assert.Equals(t, c.want, got)
+
+			// verify the built imposter round-trips to the same JSON as the literal
+			gotBytes, err := json.Marshal(got)
+			assert.MustOk(t, err)
+
+			wantBytes, err := json.Marshal(c.want)
+			assert.MustOk(t, err)
+
+			var gotImp, wantImp mbgo.Imposter
+			assert.MustOk(t, json.Unmarshal(gotBytes, &gotImp))
+			assert.MustOk(t, json.Unmarshal(wantBytes, &wantImp))
+			assert.Equals(t, wantImp, gotImp)
+		})
+	}
+}
+
+func TestImposterBuilder_Build_Copy(t *testing.T) {
+	t.Parallel()
+
+	b := mbgo.NewImposter(8080, "http").AddStub(mbgo.NewStub().Build())
+	first := b.Build()
+	b.AddStub(mbgo.NewStub().Build())
+
+	assert.Equals(t, 1, len(first.Stubs))
+	assert.Equals(t, 2, len(b.Build().Stubs))
+}
+
+func TestFormPredicate(t *testing.T) {
+	t.Parallel()
+
+	p := mbgo.FormPredicate(mbgo.PredicateDeepEquals, url.Values{
+		"name": []string{"gopher"},
+		"tags": []string{"go", "mocks"},
+	})
+
+	b, err := json.Marshal(p)
+	assert.MustOk(t, err)
+
+	var got map[string]interface{}
+	assert.MustOk(t, json.Unmarshal(b, &got))
+	assert.Equals(t, map[string]interface{}{
+		"deepEquals": map[string]interface{}{
+			"form": map[string]interface{}{
+				"name": "gopher",
+				"tags": []interface{}{"go", "mocks"},
+			},
+		},
+	}, got)
+}
+
+func TestStubBuilder_WithResponses(t *testing.T) {
+	t.Parallel()
+
+	got := mbgo.NewStub().
+		WithResponse(mbgo.Response{
+			Type:  "inject",
+			Value: "function (config) { return {}; }",
+		}).
+		WithResponses(mbgo.StatusSequence(http.StatusOK, http.StatusOK, http.StatusInternalServerError)...).
+		Build()
+
+	assert.Equals(t, mbgo.Stub{
+		Responses: []mbgo.Response{
+			{
+				Type:  "inject",
+				Value: "function (config) { return {}; }",
+			},
+			{
+				Type:  "is",
+				Value: mbgo.HTTPResponse{StatusCode: http.StatusOK},
+			},
+			{
+				Type:  "is",
+				Value: mbgo.HTTPResponse{StatusCode: http.StatusOK},
+			},
+			{
+				Type:  "is",
+				Value: mbgo.HTTPResponse{StatusCode: http.StatusInternalServerError},
+			},
+		},
+	}, got)
+}
+
+func TestStatusSequence(t *testing.T) {
+	cases := map[string]struct {
+		codes []int
+		want  []mbgo.Response
+	}{
+		"returns no responses without codes": {
+			want: []mbgo.Response{},
+		},
+		"returns an is response for a single code": {
+			codes: []int{http.StatusOK},
+			want: []mbgo.Response{
+				{Type: "is", Value: mbgo.HTTPResponse{StatusCode: http.StatusOK}},
+			},
+		},
+		"returns an is response for each code in order": {
+			codes: []int{http.StatusOK, http.StatusOK, http.StatusInternalServerError, http.StatusServiceUnavailable},
+			want: []mbgo.Response{
+				{Type: "is", Value: mbgo.HTTPResponse{StatusCode: http.StatusOK}},
+				{Type: "is", Value: mbgo.HTTPResponse{StatusCode: http.StatusOK}},
+				{Type: "is", Value: mbgo.HTTPResponse{StatusCode: http.StatusInternalServerError}},
+				{Type: "is", Value: mbgo.HTTPResponse{StatusCode: http.StatusServiceUnavailable}},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equals(t, c.want, mbgo.StatusSequence(c.codes...))
+		})
+	}
+}
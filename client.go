@@ -7,12 +7,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ogbofjnr/mbgo/internal/rest"
@@ -21,53 +22,118 @@ import (
 // Client represents a native client to the mountebank REST API.
 type Client struct {
 	restCli *rest.Client
+	root    *url.URL
+	tracer  Tracer
+}
+
+// LibraryVersion is the version of the mbgo library, which is sent in the
+// default 'User-Agent: mbgo/<version>' header of every request.
+const LibraryVersion = "0.1.0"
+
+// defaultUserAgent is the default 'User-Agent' header of every request.
+const defaultUserAgent = "mbgo/" + LibraryVersion
+
+// defaultTimeout is the timeout of the *http.Client used by a Client
+// if one is not provided.
+const defaultTimeout = 30 * time.Second
+
+// defaultMaxIdleConnsPerHost is the maximum number of idle connections to
+// the mountebank server kept by the transport of the default *http.Client.
+const defaultMaxIdleConnsPerHost = 100
+
+// resetConcurrency is the maximum number of concurrent requests sent by
+// ResetAllRequests.
+const resetConcurrency = 8
+
+// defaultTransport returns the transport of the default *http.Client used
+// by a Client, which is a copy of http.DefaultTransport keeping more idle
+// connections to the mountebank server than its default of 2.
+func defaultTransport() http.RoundTripper {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+	t = t.Clone()
+	t.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	return t
 }
 
 // NewClient returns a new instance of *Client given its underlying
-// *http.Client restCli and base *url.URL to the mountebank API root.
+// *http.Client cli and base *url.URL to the mountebank API root.
 //
-// If nil, defaults the root *url.URL value to point to http://localhost:2525.
-func NewClient(cli *http.Client, root *url.URL) *Client {
+// If nil, defaults the *http.Client value to one with a timeout of
+// 30 seconds, which keeps up to 100 idle connections to the mountebank
+// server open for reuse, such as when creating many Imposters concurrently,
+// and the root *url.URL value to point to http://localhost:2525.
+// Every request is sent with a 'User-Agent: mbgo/<version>' header by default.
+// Any provided options are applied to the Client in order.
+func NewClient(cli *http.Client, root *url.URL, opts ...Option) *Client {
+	if cli == nil {
+		cli = &http.Client{
+			Transport: defaultTransport(),
+			Timeout:   defaultTimeout,
+		}
+	}
 	if root == nil {
 		root = &url.URL{
 			Scheme: "http",
 			Host:   net.JoinHostPort("localhost", "2525"),
 		}
 	}
-	return &Client{
+	c := &Client{
 		restCli: rest.NewClient(cli, root),
+		root:    root,
 	}
+	c.restCli.AddHeaders(http.Header{
+		"User-Agent": []string{defaultUserAgent},
+	})
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// errorDTO represents the structure of an error received from the mountebank API.
-type errorDTO struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-}
-
-// decodeError is a helper method used to decode an errorDTO structure from the
-// given response body, usually when an unexpected response code is returned.
-func (cli *Client) decodeError(body io.ReadCloser) error {
+// decodeError is a helper method used to decode an *APIError from the given
+// response, usually when an unexpected response code is returned. Unknown
+// fields of the error objects, such as the "data" of injection errors, are
+// ignored even if WithStrictDecoding is used, so that the *APIError and its
+// status code are not lost. A body that cannot be decoded, such as an HTML
+// error page from a proxy in front of mountebank, returns an *APIError with
+// only the status code set.
+func (cli *Client) decodeError(resp *http.Response) error {
 	var wrap struct {
-		Errors []errorDTO `json:"errors"`
+		Errors []ErrorDetail `json:"errors"`
 	}
-	if err := cli.restCli.DecodeResponseBody(body, &wrap); err != nil {
-		return err
+	if err := cli.restCli.DecodeErrorBody(resp.Body, &wrap); err != nil {
+		return &APIError{StatusCode: resp.StatusCode}
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Errors:     wrap.Errors,
 	}
-	// Silently ignore all but the first error value if multiple are returned
-	dto := wrap.Errors[0]
-	return fmt.Errorf("%s: %s", dto.Code, dto.Message)
 }
 
 // Create creates a single new Imposter given its creation details imp.
 //
+// If imp.Port is zero, mountebank assigns a free port to the Imposter,
+// which is set as the Port of the returned Imposter.
+//
 // Note that the Imposter.RequestCount field is not used during creation.
+// An Imposter that is invalid according to Imposter.Validate or cannot be
+// marshaled, such as one with a Proxy response of an unsupported Mode,
+// returns an error without a request being sent.
 //
 // See more information on this resource at:
 // http://www.mbtest.org/docs/api/overview#post-imposters.
-func (cli *Client) Create(ctx context.Context, imp Imposter) (*Imposter, error) {
+func (cli *Client) Create(ctx context.Context, imp Imposter) (_ *Imposter, err error) {
+	ctx, span := cli.startSpan(ctx, "Create", map[string]interface{}{
+		"port":     imp.Port,
+		"protocol": imp.Proto,
+	})
+	defer func() { span.End(err) }()
+
 	p := "/imposters"
-	b, err := json.Marshal(&imp)
+	b, err := cli.RenderCreate(imp)
 	if err != nil {
 		return nil, err
 	}
@@ -87,25 +153,350 @@ func (cli *Client) Create(ctx context.Context, imp Imposter) (*Imposter, error)
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 
 	return &imp, nil
 }
 
+// RenderCreate returns the JSON request body sent by Create to create the
+// given Imposter, without sending it, such as to review the payload of a
+// complex Imposter. As with Create, an error is returned if the Imposter
+// is invalid according to Imposter.Validate or cannot be marshaled.
+func (cli *Client) RenderCreate(imp Imposter) ([]byte, error) {
+	if err := imp.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&imp)
+}
+
+// CreateAll creates each of the given Imposters, as with Create, using at most
+// concurrency concurrent requests, and returns the created Imposters in the
+// same order. Once creating an Imposter fails, or the provided context is done,
+// no further Imposters are created and the first error is returned. Note that
+// any Imposters already created are not deleted in that case.
+func (cli *Client) CreateAll(ctx context.Context, imps []Imposter, concurrency int) ([]Imposter, error) {
+	created := make([]Imposter, len(imps))
+	err := forEach(ctx, len(imps), concurrency, func(ctx context.Context, i int) error {
+		imp, err := cli.Create(ctx, imps[i])
+		if err != nil {
+			return err
+		}
+		created[i] = *imp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// forEach calls fn with each index from 0 to n-1 using a pool of at most
+// concurrency goroutines. Once a call of fn fails, or the provided context
+// is done, the context passed to fn is cancelled, no further calls are made
+// and the first error is returned.
+func forEach(ctx context.Context, n, concurrency int, fn func(ctx context.Context, i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		indexes  = make(chan int)
+	)
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	var aborted bool
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+			continue
+		case <-ctx.Done():
+			aborted = true
+		}
+		break
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if aborted {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// CreateAndWait creates a single new Imposter given its creation details imp,
+// as with Create, and then dials its port every poll interval until it accepts
+// a TCP connection, returning the created Imposter. The port is dialled on the
+// host of the mountebank API root, so it must be reachable from the client.
+// An error is returned if the poll interval is not positive, if the Imposter
+// cannot be created, or once the provided context is done. In the latter case,
+// the created Imposter is returned along with the error so that it can still
+// be deleted, such as when mountebank assigned its port.
+func (cli *Client) CreateAndWait(ctx context.Context, imp Imposter, poll time.Duration) (*Imposter, error) {
+	if err := checkPoll(poll); err != nil {
+		return nil, err
+	}
+
+	created, err := cli.Create(ctx, imp)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(cli.root.Hostname(), strconv.Itoa(created.Port))
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	var d net.Dialer
+	for {
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			conn.Close()
+			return created, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return created, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkPoll returns an error if the given poll interval is not positive.
+func checkPoll(poll time.Duration) error {
+	if poll <= 0 {
+		return fmt.Errorf("invalid poll interval: %v", poll)
+	}
+	return nil
+}
+
 // Imposter retrieves the Imposter data at the given port.
 //
-// Note that the Imposter.RecordRequests and Imposter.AllowCORS fields
-// are ignored when un-marshalling an Imposter value and should only be
-// used when creating an Imposter.
+// If replay is true, the Imposter is returned in its replayable format,
+// which excludes volatile fields such as the request count, recorded
+// requests and stub matches so that the result is suitable for
+// re-creating the Imposter elsewhere.
+//
+// If no Imposter exists on the given port, the returned error matches
+// ErrImposterNotFound using errors.Is.
 //
 // See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#get-imposter.
-func (cli *Client) Imposter(ctx context.Context, port int, replay bool) (*Imposter, error) {
-	p := fmt.Sprintf("/imposters/%d", port)
+func (cli *Client) Imposter(ctx context.Context, port int, replay bool) (_ *Imposter, err error) {
+	ctx, span := cli.startSpan(ctx, "Imposter", map[string]interface{}{
+		"port": port,
+	})
+	defer func() { span.End(err) }()
+
 	vs := url.Values{}
 	vs.Add("replayable", strconv.FormatBool(replay))
 
+	return cli.imposter(ctx, port, vs)
+}
+
+// ImposterRaw retrieves the Imposter data at the given port, as with Imposter,
+// along with the raw JSON returned by mountebank, such as to inspect fields
+// that are not modeled by Imposter.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#get-imposter.
+func (cli *Client) ImposterRaw(ctx context.Context, port int) (*Imposter, json.RawMessage, error) {
+	p := fmt.Sprintf("/imposters/%d", port)
+
+	req, err := cli.restCli.NewRequest(ctx, http.MethodGet, p, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := cli.restCli.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var raw json.RawMessage
+	if resp.StatusCode == http.StatusOK {
+		if err := cli.restCli.DecodeResponseBody(resp.Body, &raw); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		return nil, nil, cli.decodeError(resp)
+	}
+
+	var imp Imposter
+	if err := json.Unmarshal(raw, &imp); err != nil {
+		return nil, raw, err
+	}
+
+	return &imp, raw, nil
+}
+
+// ImposterReplayable retrieves the Imposter data at the given port in its
+// replayable format. If removeProxies is true, any proxy responses are also
+// removed so that only the responses recorded by them remain; this allows
+// a captured Imposter to be replayed without access to the proxied service.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#get-imposter.
+func (cli *Client) ImposterReplayable(ctx context.Context, port int, removeProxies bool) (*Imposter, error) {
+	vs := url.Values{}
+	vs.Add("replayable", strconv.FormatBool(true))
+	vs.Add("removeProxies", strconv.FormatBool(removeProxies))
+
+	return cli.imposter(ctx, port, vs)
+}
+
+// ImposterByName retrieves the data of the first registered Imposter with
+// the given name, such as one created without a port to have mountebank
+// assign a free one. An error is returned if the name is empty, and if no
+// Imposter has the given name, the returned error matches ErrImposterNotFound
+// using errors.Is.
+func (cli *Client) ImposterByName(ctx context.Context, name string) (*Imposter, error) {
+	ports, err := cli.portsByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrImposterNotFound, name)
+	}
+	return cli.Imposter(ctx, ports[0], false)
+}
+
+// portsByName returns the ports of the registered Imposters with the given
+// name in the order they are listed by mountebank. An error is returned if
+// the name is empty, which would otherwise match every unnamed Imposter.
+func (cli *Client) portsByName(ctx context.Context, name string) ([]int, error) {
+	if name == "" {
+		return nil, errors.New("imposter name is required")
+	}
+
+	// the replayable format is used since it is the only list format
+	// that includes the name of each imposter
+	imps, err := cli.ImpostersReplayable(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []int
+	for _, imp := range imps {
+		if imp.Name == name {
+			ports = append(ports, imp.Port)
+		}
+	}
+	return ports, nil
+}
+
+// StreamRequests retrieves the requests recorded by the HTTP or HTTPS Imposter
+// at the given port, calling fn with each of them in the order they were
+// received. Unlike Imposter, the requests are decoded one at a time as the
+// response is read, so that a large number of recorded requests are never
+// held in memory at once. Streaming stops at the first error returned by fn,
+// which is then returned. An error is returned without calling fn if the
+// Imposter is of another protocol than "http" or "https".
+//
+// Note that the limit set by WithMaxResponseSize does not apply to the
+// streamed response, and that unknown fields of the requests are ignored
+// even if WithStrictDecoding is used, as with the requests of Imposter.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#get-imposter.
+func (cli *Client) StreamRequests(ctx context.Context, port int, fn func(HTTPRequest) error) error {
+	p := fmt.Sprintf("/imposters/%d", port)
+
+	req, err := cli.restCli.NewRequest(ctx, http.MethodGet, p, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := cli.restCli.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return cli.decodeError(resp)
+	}
+	defer rest.CloseBody(resp.Body)
+
+	return decodeHTTPRequestStream(json.NewDecoder(resp.Body), fn)
+}
+
+// WaitForRequests polls the Imposter data at the given port every poll
+// interval until its RequestCount is at least count, returning the last
+// retrieved Imposter. An error is returned if the poll interval is not
+// positive, if the Imposter cannot be retrieved, or once the provided
+// context is done.
+func (cli *Client) WaitForRequests(ctx context.Context, port, count int, poll time.Duration) (*Imposter, error) {
+	if err := checkPoll(poll); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		imp, err := cli.Imposter(ctx, port, false)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		} else if err != nil {
+			return nil, err
+		}
+		if imp.RequestCount >= count {
+			return imp, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// StubMatches retrieves the Matches of the Stub at the given index of the
+// Imposter at the given port. An error is returned if the index is out of
+// range of the Imposter stubs. Note that matches are only recorded by a
+// mountebank server started with the --debug flag.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#get-imposter.
+func (cli *Client) StubMatches(ctx context.Context, port, index int) ([]Match, error) {
+	imp, err := cli.Imposter(ctx, port, false)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(imp.Stubs) {
+		return nil, fmt.Errorf("stub index out of range: %d", index)
+	}
+	return imp.Stubs[index].Matches, nil
+}
+
+// imposter retrieves the Imposter data at the given port using the
+// provided query parameters.
+func (cli *Client) imposter(ctx context.Context, port int, vs url.Values) (*Imposter, error) {
+	p := fmt.Sprintf("/imposters/%d", port)
+
 	req, err := cli.restCli.NewRequest(ctx, http.MethodGet, p, nil, vs)
 	if err != nil {
 		return nil, err
@@ -122,7 +513,7 @@ func (cli *Client) Imposter(ctx context.Context, port int, replay bool) (*Impost
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 
 	return &imp, nil
@@ -161,7 +552,7 @@ func (cli *Client) AddStub(ctx context.Context, port, index int, stub Stub) (*Im
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return &imp, nil
 }
@@ -169,7 +560,7 @@ func (cli *Client) AddStub(ctx context.Context, port, index int, stub Stub) (*Im
 // OverwriteStub overwrites an existing Stub without restarting its Imposter,
 // where the stub index denotes the stub to be changed.
 //
-// See more information about this resouce at:
+// See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#change-stub
 func (cli *Client) OverwriteStub(ctx context.Context, port, index int, stub Stub) (*Imposter, error) {
 	p := fmt.Sprintf("/imposters/%d/stubs/%d", port, index)
@@ -195,12 +586,44 @@ func (cli *Client) OverwriteStub(ctx context.Context, port, index int, stub Stub
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return &imp, nil
 }
 
+// RetargetProxy changes the To URL of every Proxy response of the Stub at the
+// given index of the Imposter at the given port, such as when the proxied
+// server has restarted on a new address, and then overwrites the Stub as with
+// OverwriteStub. An error is returned if the index is out of range of the
+// Imposter stubs, or if the Stub has no Proxy responses.
+func (cli *Client) RetargetProxy(ctx context.Context, port, index int, to string) (*Imposter, error) {
+	imp, err := cli.Imposter(ctx, port, true)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(imp.Stubs) {
+		return nil, fmt.Errorf("stub index out of range: %d", index)
+	}
+
+	stub := imp.Stubs[index]
+	n := 0
+	for i, r := range stub.Responses {
+		if p, ok := r.Value.(*Proxy); ok {
+			p.To = to
+			stub.Responses[i].Value = p
+			n++
+		}
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("stub has no proxy responses: %d", index)
+	}
+
+	return cli.OverwriteStub(ctx, port, index, stub)
+}
+
 // OverwriteAllStubs overwrites all existing Stubs without restarting their Imposter.
+// Unlike re-creating the Imposter, its port binding and any recorded requests
+// and request count are preserved.
 //
 // See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#change-stubs
@@ -230,7 +653,7 @@ func (cli *Client) OverwriteAllStubs(ctx context.Context, port int, stubs []Stub
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return &imp, nil
 }
@@ -258,7 +681,7 @@ func (cli *Client) RemoveStub(ctx context.Context, port, index int) (*Imposter,
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return &imp, nil
 }
@@ -269,7 +692,12 @@ func (cli *Client) RemoveStub(ctx context.Context, port, index int) (*Imposter,
 //
 // See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#delete-imposter.
-func (cli *Client) Delete(ctx context.Context, port int, replay bool) (*Imposter, error) {
+func (cli *Client) Delete(ctx context.Context, port int, replay bool) (_ *Imposter, err error) {
+	ctx, span := cli.startSpan(ctx, "Delete", map[string]interface{}{
+		"port": port,
+	})
+	defer func() { span.End(err) }()
+
 	p := fmt.Sprintf("/imposters/%d", port)
 	vs := url.Values{}
 	vs.Add("replayable", strconv.FormatBool(replay))
@@ -290,19 +718,44 @@ func (cli *Client) Delete(ctx context.Context, port int, replay bool) (*Imposter
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return &imp, nil
 }
 
+// DeleteImposter removes an Imposter configured on the given port, as with
+// Delete, and returns the deleted Imposter data, or nil if one does not exist
+// on the port, such as to report whether teardown actually removed anything.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#delete-imposter.
+func (cli *Client) DeleteImposter(ctx context.Context, port int) (*Imposter, error) {
+	imp, err := cli.Delete(ctx, port, false)
+	if err != nil {
+		return nil, err
+	}
+	// mountebank responds with an empty object if no imposter exists
+	if imp.Port == 0 {
+		return nil, nil
+	}
+	return imp, nil
+}
+
 // DeleteRequests removes any recorded requests associated with the
-// Imposter on the given port and returns the Imposter including the
-// deleted requests, or an empty Imposter struct if one does not exist
-// on the port.
+// Imposter on the given port.
+//
+// Deprecated: use DeleteSavedRequests instead.
+func (cli *Client) DeleteRequests(ctx context.Context, port int) (*Imposter, error) {
+	return cli.DeleteSavedRequests(ctx, port)
+}
+
+// DeleteSavedRequests removes any recorded requests associated with the
+// Imposter on the given port without removing the Imposter or its Stubs,
+// and returns the Imposter with its emptied list of recorded requests.
 //
 // See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#delete-imposter-requests.
-func (cli *Client) DeleteRequests(ctx context.Context, port int) (*Imposter, error) {
+func (cli *Client) DeleteSavedRequests(ctx context.Context, port int) (*Imposter, error) {
 	p := fmt.Sprintf("/imposters/%d/savedRequests", port)
 
 	req, err := cli.restCli.NewRequest(ctx, http.MethodDelete, p, nil, nil)
@@ -321,7 +774,55 @@ func (cli *Client) DeleteRequests(ctx context.Context, port int) (*Imposter, err
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
+	}
+	return &imp, nil
+}
+
+// ResetAllRequests removes any recorded requests associated with every
+// Imposter registered in mountebank, as with DeleteSavedRequests, while
+// keeping the Imposters themselves, such as to reset a shared mountebank
+// instance between tests. The requests of the Imposters are removed using
+// at most 8 concurrent requests, as with CreateAll, and once removing them
+// fails no further requests are sent and the first error is returned.
+func (cli *Client) ResetAllRequests(ctx context.Context) error {
+	imps, err := cli.Imposters(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return forEach(ctx, len(imps), resetConcurrency, func(ctx context.Context, i int) error {
+		_, err := cli.DeleteSavedRequests(ctx, imps[i].Port)
+		return err
+	})
+}
+
+// DeleteSavedProxyResponses removes any responses saved by proxies of the
+// Imposter on the given port, while keeping the Imposter and any of its
+// other Stubs, and returns the updated Imposter.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#delete-proxy-responses.
+func (cli *Client) DeleteSavedProxyResponses(ctx context.Context, port int) (*Imposter, error) {
+	p := fmt.Sprintf("/imposters/%d/savedProxyResponses", port)
+
+	req, err := cli.restCli.NewRequest(ctx, http.MethodDelete, p, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cli.restCli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var imp Imposter
+	if resp.StatusCode == http.StatusOK {
+		if err := cli.restCli.DecodeResponseBody(resp.Body, &imp); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, cli.decodeError(resp)
 	}
 	return &imp, nil
 }
@@ -333,16 +834,22 @@ type imposterListWrapper struct {
 // Overwrite is used to overwrite all registered Imposters with a new
 // set of Imposters. This call is destructive, removing all previous
 // Imposters even if the new set of Imposters do not conflict with
-// previously registered protocols/ports.
+// previously registered protocols/ports. As with Create, an error is
+// returned without a request being sent if any of the Imposters is
+// invalid according to Imposter.Validate.
 //
 // See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#put-imposters.
 func (cli *Client) Overwrite(ctx context.Context, imps []Imposter) ([]Imposter, error) {
 	p := "/imposters"
 
-	b, err := json.Marshal(&struct {
-		Imposters []Imposter `json:"imposters"`
-	}{
+	for i, imp := range imps {
+		if err := imp.Validate(); err != nil {
+			return nil, fmt.Errorf("imposter %d: %w", i, err)
+		}
+	}
+
+	b, err := json.Marshal(&imposterListWrapper{
 		Imposters: imps,
 	})
 	if err != nil {
@@ -365,20 +872,81 @@ func (cli *Client) Overwrite(ctx context.Context, imps []Imposter) ([]Imposter,
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return wrap.Imposters, nil
 }
 
 // Imposters retrieves a list of all Imposters registered in mountebank.
 //
+// Unless replay is true, each Imposter is returned in a minimal
+// representation that only includes its port, protocol and request
+// count; use Imposter to retrieve the full details of each one.
+//
 // See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#get-imposters.
 func (cli *Client) Imposters(ctx context.Context, replay bool) ([]Imposter, error) {
-	p := "/imposters"
 	vs := url.Values{}
 	vs.Add("replayable", strconv.FormatBool(replay))
 
+	return cli.imposters(ctx, vs)
+}
+
+// RequestCounts retrieves the number of requests received by the Imposters at
+// the given ports, or by every Imposter if no ports are given, keyed by port,
+// using a single request to list the Imposters. An error matching
+// ErrImposterNotFound is returned if no Imposter exists at one of the ports.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#get-imposters.
+func (cli *Client) RequestCounts(ctx context.Context, ports ...int) (map[int]int, error) {
+	imps, err := cli.Imposters(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[int]int, len(imps))
+	for _, imp := range imps {
+		all[imp.Port] = imp.RequestCount
+	}
+	if len(ports) == 0 {
+		return all, nil
+	}
+
+	counts := make(map[int]int, len(ports))
+	for _, port := range ports {
+		n, ok := all[port]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrImposterNotFound, port)
+		}
+		counts[port] = n
+	}
+	return counts, nil
+}
+
+// ImpostersReplayable retrieves a list of all Imposters registered in
+// mountebank in their replayable format, suitable for re-creating them
+// all at once using Overwrite. The creation fields of each Imposter,
+// such as RecordRequests, the AllowCORS and SSL fields of "http" and
+// "https" Imposters and the Mode and EndOfRequestResolver of "tcp"
+// Imposters, are decoded along with its Stubs. If removeProxies is true, any proxy responses are also
+// removed so that only the responses recorded by them remain.
+//
+// See more information about this resource at:
+// http://www.mbtest.org/docs/api/overview#get-imposters.
+func (cli *Client) ImpostersReplayable(ctx context.Context, removeProxies bool) ([]Imposter, error) {
+	vs := url.Values{}
+	vs.Add("replayable", strconv.FormatBool(true))
+	vs.Add("removeProxies", strconv.FormatBool(removeProxies))
+
+	return cli.imposters(ctx, vs)
+}
+
+// imposters retrieves a list of all Imposters registered in mountebank
+// using the provided query parameters.
+func (cli *Client) imposters(ctx context.Context, vs url.Values) ([]Imposter, error) {
+	p := "/imposters"
+
 	req, err := cli.restCli.NewRequest(ctx, http.MethodGet, p, nil, vs)
 	if err != nil {
 		return nil, err
@@ -395,7 +963,7 @@ func (cli *Client) Imposters(ctx context.Context, replay bool) ([]Imposter, erro
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return wrap.Imposters, nil
 }
@@ -406,7 +974,10 @@ func (cli *Client) Imposters(ctx context.Context, replay bool) ([]Imposter, erro
 //
 // See more information about this resource at:
 // http://www.mbtest.org/docs/api/overview#delete-imposters.
-func (cli *Client) DeleteAll(ctx context.Context, replay bool) ([]Imposter, error) {
+func (cli *Client) DeleteAll(ctx context.Context, replay bool) (_ []Imposter, err error) {
+	ctx, span := cli.startSpan(ctx, "DeleteAll", nil)
+	defer func() { span.End(err) }()
+
 	p := "/imposters"
 	vs := url.Values{}
 	vs.Add("replayable", strconv.FormatBool(replay))
@@ -427,11 +998,58 @@ func (cli *Client) DeleteAll(ctx context.Context, replay bool) ([]Imposter, erro
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return wrap.Imposters, nil
 }
 
+// DeleteImpostersByName removes every registered Imposter with the given
+// name, leaving any other Imposters in place, and returns the deleted
+// Imposter data. This allows test runs sharing a mountebank server to
+// only remove the Imposters they created. An error is returned without
+// deleting any Imposters if the name is empty.
+func (cli *Client) DeleteImpostersByName(ctx context.Context, name string) ([]Imposter, error) {
+	ports, err := cli.portsByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []Imposter
+	for _, port := range ports {
+		del, err := cli.Delete(ctx, port, false)
+		if err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, *del)
+	}
+	return deleted, nil
+}
+
+// Ping checks that the mountebank server pointed to by the client is
+// reachable by requesting its root resource, returning nil if the
+// server responds with a 200 OK status code.
+//
+// See more information on this resource at:
+// http://www.mbtest.org/docs/api/overview#get-home.
+func (cli *Client) Ping(ctx context.Context) error {
+	p := "/"
+
+	req, err := cli.restCli.NewRequest(ctx, http.MethodGet, p, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := cli.restCli.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return cli.decodeError(resp)
+	}
+	return rest.CloseBody(resp.Body)
+}
+
 // Config represents information about the configuration of the mountebank
 // server runtime, including its version, options and runtime information.
 //
@@ -442,20 +1060,7 @@ type Config struct {
 	Version string `json:"version"`
 
 	// Options represent runtime options of the mountebank server process.
-	Options struct {
-		Help           bool     `json:"help"`
-		NoParse        bool     `json:"noParse"`
-		NoLogFile      bool     `json:"nologfile"`
-		AllowInjection bool     `json:"allowInjection"`
-		LocalOnly      bool     `json:"localOnly"`
-		Mock           bool     `json:"mock"`
-		Debug          bool     `json:"debug"`
-		Port           int      `json:"port"`
-		PIDFile        string   `json:"pidfile"`
-		LogFile        string   `json:"logfile"`
-		LogLevel       string   `json:"loglevel"`
-		IPWhitelist    []string `json:"ipWhitelist"`
-	} `json:"options"`
+	Options ConfigOptions `json:"options"`
 
 	// Process represents information about the mountebank server NodeJS runtime.
 	Process struct {
@@ -470,6 +1075,29 @@ type Config struct {
 	} `json:"process"`
 }
 
+// ConfigOptions represents the runtime options of the mountebank server
+// process in its Config.
+type ConfigOptions struct {
+	Help           bool     `json:"help"`
+	NoParse        bool     `json:"noParse"`
+	NoLogFile      bool     `json:"nologfile"`
+	AllowInjection bool     `json:"allowInjection"`
+	LocalOnly      bool     `json:"localOnly"`
+	Mock           bool     `json:"mock"`
+	Debug          bool     `json:"debug"`
+	Port           int      `json:"port"`
+	PIDFile        string   `json:"pidfile"`
+	LogFile        string   `json:"logfile"`
+	LogLevel       string   `json:"loglevel"`
+	IPWhitelist    []string `json:"ipWhitelist"`
+
+	// Other are any other options of the mountebank server by name, such
+	// as those added in later versions of mountebank. They are decoded
+	// even if WithStrictDecoding is used, as the options of mountebank
+	// vary by version and command line.
+	Other map[string]interface{} `json:"-"`
+}
+
 // Config retrieves the configuration information of the mountebank
 // server pointed to by the client.
 //
@@ -494,11 +1122,24 @@ func (cli *Client) Config(ctx context.Context) (*Config, error) {
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return &cfg, nil
 }
 
+// Version retrieves the version of the mountebank server pointed to by
+// the client in semantic M.m.p format, as in the Config of the server.
+//
+// See more information on this resource at:
+// http://www.mbtest.org/docs/api/overview#get-config.
+func (cli *Client) Version(ctx context.Context) (string, error) {
+	cfg, err := cli.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Version, nil
+}
+
 // Log represents a log entry value in mountebank.
 //
 // See more information about its full structure at:
@@ -544,7 +1185,7 @@ func (cli *Client) Logs(ctx context.Context, start, end int) ([]Log, error) {
 			return nil, err
 		}
 	} else {
-		return nil, cli.decodeError(resp.Body)
+		return nil, cli.decodeError(resp)
 	}
 	return wrap.Logs, nil
 }
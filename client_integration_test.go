@@ -9,8 +9,10 @@ package mbgo_test
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,13 +22,13 @@ import (
 
 // newMountebankClient creates a new mountebank client instance pointing to the host
 // denoted by the MB_HOST environment variable, or localhost:2525 if blank.
-func newMountebankClient() *mbgo.Client {
+func newMountebankClient(opts ...mbgo.Option) *mbgo.Client {
 	return mbgo.NewClient(&http.Client{
 		Timeout: time.Second,
 	}, &url.URL{
 		Scheme: "http",
 		Host:   "localhost:2525",
-	})
+	}, opts...)
 }
 
 // newContext returns a new context instance with the given timeout.
@@ -35,15 +37,69 @@ func newContext(timeout time.Duration) context.Context {
 	return ctx
 }
 
+// clearLinks clears out the hypermedia links of the given imposter and its
+// stubs before doing a deep equality check, as they depend on the host of
+// the mountebank server.
+func clearLinks(imp *mbgo.Imposter) {
+	if imp == nil {
+		return
+	}
+	imp.Links = nil
+	for i := range imp.Stubs {
+		imp.Stubs[i].Links = nil
+	}
+}
+
+// clearAllLinks calls clearLinks on each of the given imposters.
+func clearAllLinks(imps []mbgo.Imposter) {
+	for i := range imps {
+		clearLinks(&imps[i])
+	}
+}
+
+func TestClient_DeleteImpostersByName_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+
+	for _, imp := range []mbgo.Imposter{
+		{Port: 8080, Proto: "tcp", Name: "delete_by_name_test"},
+		{Port: 8081, Proto: "tcp", Name: "delete_by_name_test_kept"},
+	} {
+		_, err = mb.Create(newContext(time.Second), imp)
+		assert.MustOk(t, err)
+	}
+
+	deleted, err := mb.DeleteImpostersByName(newContext(time.Second), "delete_by_name_test")
+	assert.MustOk(t, err)
+	assert.Equals(t, 1, len(deleted))
+	assert.Equals(t, 8080, deleted[0].Port)
+
+	imps, err := mb.ImpostersReplayable(newContext(time.Second), false)
+	assert.MustOk(t, err)
+	assert.Equals(t, 1, len(imps))
+	assert.Equals(t, "delete_by_name_test_kept", imps[0].Name)
+
+	_, err = mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+}
+
 func TestClient_Logs_Integration(t *testing.T) {
 	mb := newMountebankClient()
 
 	vs, err := mb.Logs(newContext(time.Second), -1, -1)
 	assert.MustOk(t, err)
-	assert.Equals(t, true, len(vs) >= 2)
+	assert.Equals(t, true, len(vs) >= 3)
 	assert.Equals(t, "[mb:2525] mountebank v2.1.2 now taking orders - point your browser to http://localhost:2525/ for help", vs[0].Message)
 	assert.Equals(t, "[mb:2525] Running with --allowInjection set. See http://localhost:2525/docs/security for security info", vs[1].Message)
 	assert.Equals(t, "[mb:2525] GET /logs", vs[2].Message)
+
+	// only include the log values within the given index range
+	vs, err = mb.Logs(newContext(time.Second), 1, 1)
+	assert.MustOk(t, err)
+	assert.Equals(t, 1, len(vs))
+	assert.Equals(t, "[mb:2525] Running with --allowInjection set. See http://localhost:2525/docs/security for security info", vs[0].Message)
 }
 
 func TestClient_Create_Integration(t *testing.T) {
@@ -78,6 +134,13 @@ func TestClient_Create_Integration(t *testing.T) {
 			},
 			Err: errors.New("bad data: the udp protocol is not yet supported"),
 		},
+		{
+			Description: "should error without a request being sent if the protocol is missing",
+			Input: mbgo.Imposter{
+				Port: 8080,
+			},
+			Err: errors.New("invalid imposter: Proto is required"),
+		},
 		{
 			Description: "should create the expected HTTP Imposter on success",
 			Input: mbgo.Imposter{
@@ -357,10 +420,11 @@ func TestClient_Create_Integration(t *testing.T) {
 
 			actual, err := mb.Create(newContext(time.Second), c.Input)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -370,6 +434,106 @@ func TestClient_Create_Integration(t *testing.T) {
 	}
 }
 
+func TestClient_Create_APIError_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+		Proto: "udp",
+		Port:  8080,
+	})
+
+	var apiErr *mbgo.APIError
+	assert.Equals(t, true, errors.As(err, &apiErr))
+	assert.Equals(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equals(t, 1, len(apiErr.Errors))
+	assert.Equals(t, "bad data", apiErr.Errors[0].Code)
+	assert.Equals(t, "the udp protocol is not yet supported", apiErr.Errors[0].Message)
+}
+
+func TestClient_Create_AssignedPort_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	actual, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+		Proto: "http",
+		Name:  "create_assigned_port_test",
+	})
+	assert.MustOk(t, err)
+	assert.Equals(t, true, actual.Port > 0)
+
+	imp, err := mb.Imposter(newContext(time.Second), actual.Port, false)
+	assert.MustOk(t, err)
+	assert.Equals(t, "create_assigned_port_test", imp.Name)
+
+	_, err = mb.Delete(newContext(time.Second), actual.Port, false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_CreateAndWait_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	actual, err := mb.CreateAndWait(newContext(time.Second), mbgo.Imposter{
+		Proto: "tcp",
+		Port:  8080,
+		Name:  "create_and_wait_test",
+	}, 10*time.Millisecond)
+	assert.MustOk(t, err)
+	clearLinks(actual)
+	assert.Equals(t, &mbgo.Imposter{
+		Proto: "tcp",
+		Port:  8080,
+		Name:  "create_and_wait_test",
+	}, actual)
+
+	conn, err := net.DialTimeout("tcp", "localhost:8080", time.Second)
+	assert.MustOk(t, err)
+	assert.MustOk(t, conn.Close())
+
+	_, err = mb.Delete(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_Imposter_Links_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+		Port:  8080,
+		Proto: "http",
+		Name:  "imposter_links_test",
+		Stubs: []mbgo.Stub{
+			{
+				Responses: []mbgo.Response{
+					{
+						Type: "is",
+						Value: mbgo.HTTPResponse{
+							StatusCode: http.StatusOK,
+						},
+					},
+				},
+			},
+		},
+	})
+	assert.MustOk(t, err)
+
+	imp, err := mb.Imposter(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+	assert.Equals(t, "http://localhost:2525/imposters/8080", imp.Links["self"].Href)
+	assert.Equals(t, 1, len(imp.Stubs))
+	assert.Equals(t, "http://localhost:2525/imposters/8080/stubs/0", imp.Stubs[0].Links["self"].Href)
+
+	_, err = mb.Delete(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_Imposter_NotFound_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.Delete(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+
+	_, err = mb.Imposter(newContext(time.Second), 8080, false)
+	assert.Equals(t, true, errors.Is(err, mbgo.ErrImposterNotFound))
+}
+
 func TestClient_Imposter_Integration(t *testing.T) {
 	mb := newMountebankClient()
 
@@ -442,7 +606,7 @@ func TestClient_Imposter_Integration(t *testing.T) {
 				Port:           8080,
 				Proto:          "tcp",
 				Name:           "imposter_test",
-				RecordRequests: false, // this field is only used for creation
+				RecordRequests: true,
 				RequestCount:   0,
 				Stubs: []mbgo.Stub{
 					{
@@ -466,6 +630,57 @@ func TestClient_Imposter_Integration(t *testing.T) {
 				},
 			},
 		},
+		{
+			Description: "should return the replayable TCP Imposter if it exists on the specified port",
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+
+				_, err = mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:           8080,
+					Proto:          "tcp",
+					Name:           "imposter_replay_test",
+					RecordRequests: true,
+					Stubs: []mbgo.Stub{
+						{
+							Responses: []mbgo.Response{
+								{
+									Type: "is",
+									Value: mbgo.TCPResponse{
+										Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+									},
+								},
+							},
+						},
+					},
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Port:   8080,
+			Replay: true,
+			Expected: &mbgo.Imposter{
+				Port:           8080,
+				Proto:          "tcp",
+				Name:           "imposter_replay_test",
+				RecordRequests: true,
+				Stubs: []mbgo.Stub{
+					{
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: &mbgo.TCPResponse{
+									Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -476,10 +691,11 @@ func TestClient_Imposter_Integration(t *testing.T) {
 
 			actual, err := mb.Imposter(newContext(time.Second), c.Port, c.Replay)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -489,15 +705,39 @@ func TestClient_Imposter_Integration(t *testing.T) {
 	}
 }
 
-func TestClient_AddStub_Integration(t *testing.T) {
+func TestClient_ImposterByName_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+
+	_, err = mb.ImposterByName(newContext(time.Second), "imposter_by_name_test")
+	assert.Equals(t, true, errors.Is(err, mbgo.ErrImposterNotFound))
+	assert.EqualError(t, errors.New("imposter not found: imposter_by_name_test"), err)
+
+	created, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+		Proto: "http",
+		Name:  "imposter_by_name_test",
+	})
+	assert.MustOk(t, err)
+
+	actual, err := mb.ImposterByName(newContext(time.Second), "imposter_by_name_test")
+	assert.MustOk(t, err)
+	assert.Equals(t, created.Port, actual.Port)
+	assert.Equals(t, "imposter_by_name_test", actual.Name)
+
+	_, err = mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_ImposterReplayable_Integration(t *testing.T) {
 	mb := newMountebankClient()
 
 	cases := map[string]struct {
-		Before func(*testing.T, *mbgo.Client)
-		After  func(*testing.T, *mbgo.Client)
-		Port   int
-		Index  int
-		Stub   mbgo.Stub
+		Before        func(*testing.T, *mbgo.Client)
+		After         func(*testing.T, *mbgo.Client)
+		Port          int
+		RemoveProxies bool
 
 		// output expectations
 		Expected *mbgo.Imposter
@@ -511,42 +751,16 @@ func TestClient_AddStub_Integration(t *testing.T) {
 			},
 			Err: errors.New("no such resource: Try POSTing to /imposters first?"),
 		},
-		"should update the stubs on the imposter if it exists on the specified port": {
-			Port:  8080,
-			Index: 0,
-			Stub: mbgo.Stub{
-				Predicates: []mbgo.Predicate{
-					{
-						Operator: "endsWith",
-						Request: mbgo.TCPRequest{
-							Data: "foo",
-						},
-					},
-				},
-				Responses: []mbgo.Response{
-					{
-						Type: "is",
-						Value: mbgo.TCPResponse{
-							Data: "bar",
-						},
-					},
-				},
-			},
+		"should return the replayable imposter without proxies if it exists on the specified port": {
+			Port:          8080,
+			RemoveProxies: true,
 			Before: func(t *testing.T, mb *mbgo.Client) {
 				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
 					Port:  8080,
 					Proto: "tcp",
-					Name:  "add_stub_test",
+					Name:  "imposter_replayable_test",
 					Stubs: []mbgo.Stub{
 						{
-							Predicates: []mbgo.Predicate{
-								{
-									Operator: "endsWith",
-									Request: mbgo.TCPRequest{
-										Data: "SGVsbG8sIHdvcmxkIQ==",
-									},
-								},
-							},
 							Responses: []mbgo.Response{
 								{
 									Type: "is",
@@ -560,42 +774,16 @@ func TestClient_AddStub_Integration(t *testing.T) {
 				})
 				assert.MustOk(t, err)
 			},
-			After: func(t *testing.T, client *mbgo.Client) {
+			After: func(t *testing.T, mb *mbgo.Client) {
 				_, err := mb.Delete(newContext(time.Second), 8080, false)
 				assert.MustOk(t, err)
 			},
 			Expected: &mbgo.Imposter{
 				Port:  8080,
 				Proto: "tcp",
-				Name:  "add_stub_test",
+				Name:  "imposter_replayable_test",
 				Stubs: []mbgo.Stub{
 					{
-						Predicates: []mbgo.Predicate{
-							{
-								Operator: "endsWith",
-								Request: &mbgo.TCPRequest{
-									Data: "foo",
-								},
-							},
-						},
-						Responses: []mbgo.Response{
-							{
-								Type: "is",
-								Value: &mbgo.TCPResponse{
-									Data: "bar",
-								},
-							},
-						},
-					},
-					{
-						Predicates: []mbgo.Predicate{
-							{
-								Operator: "endsWith",
-								Request: &mbgo.TCPRequest{
-									Data: "SGVsbG8sIHdvcmxkIQ==",
-								},
-							},
-						},
 						Responses: []mbgo.Response{
 							{
 								Type: "is",
@@ -618,12 +806,13 @@ func TestClient_AddStub_Integration(t *testing.T) {
 				c.Before(t, mb)
 			}
 
-			actual, err := mb.AddStub(newContext(time.Second), c.Port, c.Index, c.Stub)
+			actual, err := mb.ImposterReplayable(newContext(time.Second), c.Port, c.RemoveProxies)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -633,19 +822,18 @@ func TestClient_AddStub_Integration(t *testing.T) {
 	}
 }
 
-func TestClient_OverwriteStub_Integration(t *testing.T) {
+func TestClient_WaitForRequests_Integration(t *testing.T) {
 	mb := newMountebankClient()
 
 	cases := map[string]struct {
 		Before func(*testing.T, *mbgo.Client)
 		After  func(*testing.T, *mbgo.Client)
 		Port   int
-		Index  int
-		Stub   mbgo.Stub
+		Count  int
 
 		// output expectations
-		Expected *mbgo.Imposter
-		Err      error
+		RequestCount int
+		Err          error
 	}{
 		"should error if an imposter does not exist on the specified port": {
 			Port: 8080,
@@ -655,78 +843,748 @@ func TestClient_OverwriteStub_Integration(t *testing.T) {
 			},
 			Err: errors.New("no such resource: Try POSTing to /imposters first?"),
 		},
-		"should overwrite the stub on the imposter if it exists on the specified port": {
+		"should error if the imposter does not receive enough requests before the context is done": {
 			Port:  8080,
-			Index: 0,
-			Stub: mbgo.Stub{
-				Predicates: []mbgo.Predicate{
-					{
-						Operator: "endsWith",
-						Request: mbgo.TCPRequest{
-							Data: "foo",
-						},
-					},
-				},
-				Responses: []mbgo.Response{
-					{
-						Type: "is",
-						Value: mbgo.TCPResponse{
-							Data: "bar",
-						},
-					},
-				},
+			Count: 1,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "http",
+					Name:  "wait_for_requests_test",
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
 			},
+			Err: context.DeadlineExceeded,
+		},
+		"should return the imposter once it has received the expected number of requests": {
+			Port:  8080,
+			Count: 2,
 			Before: func(t *testing.T, mb *mbgo.Client) {
 				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
 					Port:  8080,
-					Proto: "tcp",
-					Name:  "overwrite_stub_test",
-					Stubs: []mbgo.Stub{
-						{
-							Predicates: []mbgo.Predicate{
-								{
-									Operator: "endsWith",
-									Request: mbgo.TCPRequest{
-										Data: "SGVsbG8sIHdvcmxkIQ==",
-									},
-								},
-							},
-							Responses: []mbgo.Response{
-								{
-									Type: "is",
-									Value: mbgo.TCPResponse{
-										Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
-									},
-								},
+					Proto: "http",
+					Name:  "wait_for_requests_test",
+				})
+				assert.MustOk(t, err)
+
+				go func() {
+					for i := 0; i < 2; i++ {
+						time.Sleep(50 * time.Millisecond)
+						resp, err := http.Get("http://localhost:8080/foo")
+						if err == nil {
+							resp.Body.Close()
+						}
+					}
+				}()
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			RequestCount: 2,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			if c.Before != nil {
+				c.Before(t, mb)
+			}
+
+			actual, err := mb.WaitForRequests(newContext(500*time.Millisecond), c.Port, c.Count, 10*time.Millisecond)
+			if c.Err != nil {
+				assert.EqualError(t, c.Err, err)
+			} else {
+				assert.MustOk(t, err)
+				assert.Equals(t, c.RequestCount, actual.RequestCount)
+			}
+
+			if c.After != nil {
+				c.After(t, mb)
+			}
+		})
+	}
+}
+
+func TestClient_StubMatches_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	cases := map[string]struct {
+		Before func(*testing.T, *mbgo.Client)
+		After  func(*testing.T, *mbgo.Client)
+		Port   int
+		Index  int
+
+		// output expectations
+		Expected []mbgo.Match
+		Err      error
+	}{
+		"should error if an imposter does not exist on the specified port": {
+			Port: 8080,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("no such resource: Try POSTing to /imposters first?"),
+		},
+		"should error if the stub index is out of range": {
+			Port:  8080,
+			Index: 1,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "http",
+					Name:  "stub_matches_test",
+					Stubs: []mbgo.Stub{
+						{
+							Responses: []mbgo.Response{
+								{
+									Type: "is",
+									Value: mbgo.HTTPResponse{
+										StatusCode: http.StatusOK,
+									},
+								},
+							},
+						},
+					},
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("stub index out of range: 1"),
+		},
+		"should return no matches if the server is not started with the debug flag": {
+			Port:  8080,
+			Index: 0,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "http",
+					Name:  "stub_matches_test",
+					Stubs: []mbgo.Stub{
+						{
+							Responses: []mbgo.Response{
+								{
+									Type: "is",
+									Value: mbgo.HTTPResponse{
+										StatusCode: http.StatusOK,
+									},
+								},
+							},
+						},
+					},
+				})
+				assert.MustOk(t, err)
+
+				resp, err := http.Get("http://localhost:8080/foo")
+				assert.MustOk(t, err)
+				assert.MustOk(t, resp.Body.Close())
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			if c.Before != nil {
+				c.Before(t, mb)
+			}
+
+			actual, err := mb.StubMatches(newContext(time.Second), c.Port, c.Index)
+			if c.Err != nil {
+				assert.EqualError(t, c.Err, err)
+			} else {
+				assert.Ok(t, err)
+			}
+			assert.Equals(t, c.Expected, actual)
+
+			if c.After != nil {
+				c.After(t, mb)
+			}
+		})
+	}
+}
+
+func TestClient_Create_StructuredBodyPredicate_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+		Port:  8080,
+		Proto: "http",
+		Name:  "structured_body_predicate_test",
+		Stubs: []mbgo.Stub{
+			{
+				Predicates: []mbgo.Predicate{
+					{
+						Operator: mbgo.PredicateDeepEquals,
+						Request: mbgo.HTTPRequest{
+							Body: map[string]interface{}{
+								"name": "gopher",
+								"tags": []interface{}{"go", "mocks"},
+							},
+						},
+					},
+				},
+				Responses: []mbgo.Response{
+					{
+						Type: "is",
+						Value: mbgo.HTTPResponse{
+							StatusCode: http.StatusCreated,
+						},
+					},
+				},
+			},
+		},
+	})
+	assert.MustOk(t, err)
+
+	body := strings.NewReader(`{ "tags": ["go", "mocks"], "name": "gopher" }`)
+	resp, err := http.Post("http://localhost:8080/users", "application/json", body)
+	assert.MustOk(t, err)
+	assert.MustOk(t, resp.Body.Close())
+	assert.Equals(t, http.StatusCreated, resp.StatusCode)
+
+	_, err = mb.Delete(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_Create_DefaultResponse_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+		Port:  8080,
+		Proto: "http",
+		Name:  "default_response_test",
+		DefaultResponse: mbgo.HTTPResponse{
+			StatusCode: http.StatusServiceUnavailable,
+		},
+	})
+	assert.MustOk(t, err)
+
+	resp, err := http.Get("http://localhost:8080/unmatched")
+	assert.MustOk(t, err)
+	assert.MustOk(t, resp.Body.Close())
+	assert.Equals(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	_, err = mb.Delete(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_AddStub_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	cases := map[string]struct {
+		Before func(*testing.T, *mbgo.Client)
+		After  func(*testing.T, *mbgo.Client)
+		Port   int
+		Index  int
+		Stub   mbgo.Stub
+
+		// output expectations
+		Expected *mbgo.Imposter
+		Err      error
+	}{
+		"should error if an imposter does not exist on the specified port": {
+			Port: 8080,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("no such resource: Try POSTing to /imposters first?"),
+		},
+		"should update the stubs on the imposter if it exists on the specified port": {
+			Port:  8080,
+			Index: 0,
+			Stub: mbgo.Stub{
+				Predicates: []mbgo.Predicate{
+					{
+						Operator: "endsWith",
+						Request: mbgo.TCPRequest{
+							Data: "foo",
+						},
+					},
+				},
+				Responses: []mbgo.Response{
+					{
+						Type: "is",
+						Value: mbgo.TCPResponse{
+							Data: "bar",
+						},
+					},
+				},
+			},
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "tcp",
+					Name:  "add_stub_test",
+					Stubs: []mbgo.Stub{
+						{
+							Predicates: []mbgo.Predicate{
+								{
+									Operator: "endsWith",
+									Request: mbgo.TCPRequest{
+										Data: "SGVsbG8sIHdvcmxkIQ==",
+									},
+								},
+							},
+							Responses: []mbgo.Response{
+								{
+									Type: "is",
+									Value: mbgo.TCPResponse{
+										Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+									},
+								},
+							},
+						},
+					},
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, client *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Expected: &mbgo.Imposter{
+				Port:  8080,
+				Proto: "tcp",
+				Name:  "add_stub_test",
+				Stubs: []mbgo.Stub{
+					{
+						Predicates: []mbgo.Predicate{
+							{
+								Operator: "endsWith",
+								Request: &mbgo.TCPRequest{
+									Data: "foo",
+								},
+							},
+						},
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: &mbgo.TCPResponse{
+									Data: "bar",
+								},
+							},
+						},
+					},
+					{
+						Predicates: []mbgo.Predicate{
+							{
+								Operator: "endsWith",
+								Request: &mbgo.TCPRequest{
+									Data: "SGVsbG8sIHdvcmxkIQ==",
+								},
+							},
+						},
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: &mbgo.TCPResponse{
+									Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"should append the stub to the end of the imposter stubs if the index is negative": {
+			Port:  8080,
+			Index: -1,
+			Stub: mbgo.Stub{
+				Predicates: []mbgo.Predicate{
+					{
+						Operator: "endsWith",
+						Request: mbgo.TCPRequest{
+							Data: "foo",
+						},
+					},
+				},
+				Responses: []mbgo.Response{
+					{
+						Type: "is",
+						Value: mbgo.TCPResponse{
+							Data: "bar",
+						},
+					},
+				},
+			},
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "tcp",
+					Name:  "add_stub_test",
+					Stubs: []mbgo.Stub{
+						{
+							Predicates: []mbgo.Predicate{
+								{
+									Operator: "endsWith",
+									Request: mbgo.TCPRequest{
+										Data: "SGVsbG8sIHdvcmxkIQ==",
+									},
+								},
+							},
+							Responses: []mbgo.Response{
+								{
+									Type: "is",
+									Value: mbgo.TCPResponse{
+										Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+									},
+								},
+							},
+						},
+					},
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, client *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Expected: &mbgo.Imposter{
+				Port:  8080,
+				Proto: "tcp",
+				Name:  "add_stub_test",
+				Stubs: []mbgo.Stub{
+					{
+						Predicates: []mbgo.Predicate{
+							{
+								Operator: "endsWith",
+								Request: &mbgo.TCPRequest{
+									Data: "SGVsbG8sIHdvcmxkIQ==",
+								},
+							},
+						},
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: &mbgo.TCPResponse{
+									Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+								},
+							},
+						},
+					},
+					{
+						Predicates: []mbgo.Predicate{
+							{
+								Operator: "endsWith",
+								Request: &mbgo.TCPRequest{
+									Data: "foo",
+								},
+							},
+						},
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: &mbgo.TCPResponse{
+									Data: "bar",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			if c.Before != nil {
+				c.Before(t, mb)
+			}
+
+			actual, err := mb.AddStub(newContext(time.Second), c.Port, c.Index, c.Stub)
+			if c.Err != nil {
+				assert.EqualError(t, c.Err, err)
+			} else {
+				assert.Ok(t, err)
+			}
+			clearLinks(actual)
+			assert.Equals(t, c.Expected, actual)
+
+			if c.After != nil {
+				c.After(t, mb)
+			}
+		})
+	}
+}
+
+func TestClient_OverwriteStub_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	cases := map[string]struct {
+		Before func(*testing.T, *mbgo.Client)
+		After  func(*testing.T, *mbgo.Client)
+		Port   int
+		Index  int
+		Stub   mbgo.Stub
+
+		// output expectations
+		Expected *mbgo.Imposter
+		Err      error
+	}{
+		"should error if an imposter does not exist on the specified port": {
+			Port: 8080,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("no such resource: Try POSTing to /imposters first?"),
+		},
+		"should error if the stub at the specified index does not exist": {
+			Port:  8080,
+			Index: 1,
+			Stub: mbgo.Stub{
+				Responses: []mbgo.Response{
+					{
+						Type: "is",
+						Value: mbgo.TCPResponse{
+							Data: "bar",
+						},
+					},
+				},
+			},
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "tcp",
+					Name:  "overwrite_stub_test",
+					Stubs: []mbgo.Stub{},
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, client *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("bad data: 'stubIndex' must be a valid integer, representing the array index position of the stub to replace"),
+		},
+		"should overwrite the stub on the imposter if it exists on the specified port": {
+			Port:  8080,
+			Index: 0,
+			Stub: mbgo.Stub{
+				Predicates: []mbgo.Predicate{
+					{
+						Operator: "endsWith",
+						Request: mbgo.TCPRequest{
+							Data: "foo",
+						},
+					},
+				},
+				Responses: []mbgo.Response{
+					{
+						Type: "is",
+						Value: mbgo.TCPResponse{
+							Data: "bar",
+						},
+					},
+				},
+			},
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "tcp",
+					Name:  "overwrite_stub_test",
+					Stubs: []mbgo.Stub{
+						{
+							Predicates: []mbgo.Predicate{
+								{
+									Operator: "endsWith",
+									Request: mbgo.TCPRequest{
+										Data: "SGVsbG8sIHdvcmxkIQ==",
+									},
+								},
+							},
+							Responses: []mbgo.Response{
+								{
+									Type: "is",
+									Value: mbgo.TCPResponse{
+										Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+									},
+								},
+							},
+						},
+					},
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, client *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Expected: &mbgo.Imposter{
+				Port:  8080,
+				Proto: "tcp",
+				Name:  "overwrite_stub_test",
+				Stubs: []mbgo.Stub{
+					{
+						Predicates: []mbgo.Predicate{
+							{
+								Operator: "endsWith",
+								Request: &mbgo.TCPRequest{
+									Data: "foo",
+								},
+							},
+						},
+						Responses: []mbgo.Response{
+							{
+								Type: "is",
+								Value: &mbgo.TCPResponse{
+									Data: "bar",
+								},
 							},
 						},
 					},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			if c.Before != nil {
+				c.Before(t, mb)
+			}
+
+			actual, err := mb.OverwriteStub(newContext(time.Second), c.Port, c.Index, c.Stub)
+			if c.Err != nil {
+				assert.EqualError(t, c.Err, err)
+			} else {
+				assert.Ok(t, err)
+			}
+			clearLinks(actual)
+			assert.Equals(t, c.Expected, actual)
+
+			if c.After != nil {
+				c.After(t, mb)
+			}
+		})
+	}
+}
+
+func TestClient_RetargetProxy_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	stubs := []mbgo.Stub{
+		{
+			Responses: []mbgo.Response{
+				{
+					Type: "is",
+					Value: mbgo.HTTPResponse{
+						StatusCode: http.StatusOK,
+					},
+				},
+			},
+		},
+		{
+			Responses: []mbgo.Response{
+				{
+					Type: "proxy",
+					Value: mbgo.Proxy{
+						To:   "http://localhost:8081",
+						Mode: mbgo.ProxyTransparent,
+					},
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		Before func(*testing.T, *mbgo.Client)
+		After  func(*testing.T, *mbgo.Client)
+		Port   int
+		Index  int
+		To     string
+
+		// output expectations
+		Expected *mbgo.Imposter
+		Err      error
+	}{
+		"should error if an imposter does not exist on the specified port": {
+			Port: 8080,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("no such resource: Try POSTing to /imposters first?"),
+		},
+		"should error if the stub has no proxy responses": {
+			Port:  8080,
+			Index: 0,
+			To:    "http://localhost:8082",
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "http",
+					Name:  "retarget_proxy_test",
+					Stubs: stubs,
 				})
 				assert.MustOk(t, err)
 			},
-			After: func(t *testing.T, client *mbgo.Client) {
+			After: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("stub has no proxy responses: 0"),
+		},
+		"should change the target of the proxy responses of the stub": {
+			Port:  8080,
+			Index: 1,
+			To:    "http://localhost:8082",
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "http",
+					Name:  "retarget_proxy_test",
+					Stubs: stubs,
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
 				_, err := mb.Delete(newContext(time.Second), 8080, false)
 				assert.MustOk(t, err)
 			},
 			Expected: &mbgo.Imposter{
 				Port:  8080,
-				Proto: "tcp",
-				Name:  "overwrite_stub_test",
+				Proto: "http",
+				Name:  "retarget_proxy_test",
 				Stubs: []mbgo.Stub{
 					{
-						Predicates: []mbgo.Predicate{
+						Responses: []mbgo.Response{
 							{
-								Operator: "endsWith",
-								Request: &mbgo.TCPRequest{
-									Data: "foo",
+								Type: "is",
+								Value: &mbgo.HTTPResponse{
+									StatusCode: http.StatusOK,
 								},
 							},
 						},
+					},
+					{
 						Responses: []mbgo.Response{
 							{
-								Type: "is",
-								Value: &mbgo.TCPResponse{
-									Data: "bar",
+								Type: "proxy",
+								Value: &mbgo.Proxy{
+									To:   "http://localhost:8082",
+									Mode: mbgo.ProxyTransparent,
 								},
 							},
 						},
@@ -744,12 +1602,13 @@ func TestClient_OverwriteStub_Integration(t *testing.T) {
 				c.Before(t, mb)
 			}
 
-			actual, err := mb.OverwriteStub(newContext(time.Second), c.Port, c.Index, c.Stub)
+			actual, err := mb.RetargetProxy(newContext(time.Second), c.Port, c.Index, c.To)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -908,10 +1767,11 @@ func TestClient_OverwriteAllStubs_Integration(t *testing.T) {
 
 			actual, err := mb.OverwriteAllStubs(newContext(time.Second), c.Port, c.Stubs)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -1014,10 +1874,11 @@ func TestClient_RemoveStub_Integration(t *testing.T) {
 
 			actual, err := mb.RemoveStub(newContext(time.Second), c.Port, c.Index)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -1063,10 +1924,11 @@ func TestClient_Delete_Integration(t *testing.T) {
 
 			actual, err := mb.Delete(newContext(time.Second), c.Port, c.Replay)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -1076,7 +1938,7 @@ func TestClient_Delete_Integration(t *testing.T) {
 	}
 }
 
-func TestClient_DeleteRequests_Integration(t *testing.T) {
+func TestClient_DeleteSavedRequests_Integration(t *testing.T) {
 	mb := newMountebankClient()
 
 	cases := []struct {
@@ -1122,10 +1984,11 @@ func TestClient_DeleteRequests_Integration(t *testing.T) {
 			},
 			Port: 8080,
 			Expected: &mbgo.Imposter{
-				Port:         8080,
-				Proto:        "http",
-				Name:         "delete_requests_test",
-				RequestCount: 0,
+				Port:           8080,
+				Proto:          "http",
+				Name:           "delete_requests_test",
+				RecordRequests: true,
+				RequestCount:   0,
 			},
 		},
 	}
@@ -1136,9 +1999,9 @@ func TestClient_DeleteRequests_Integration(t *testing.T) {
 				c.Before(t, mb)
 			}
 
-			actual, err := mb.DeleteRequests(newContext(time.Second), c.Port)
+			actual, err := mb.DeleteSavedRequests(newContext(time.Second), c.Port)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
@@ -1156,6 +2019,7 @@ func TestClient_DeleteRequests_Integration(t *testing.T) {
 					actual.Requests[i] = req
 				}
 
+				clearLinks(actual)
 				assert.Equals(t, c.Expected, actual)
 			}
 
@@ -1166,12 +2030,195 @@ func TestClient_DeleteRequests_Integration(t *testing.T) {
 	}
 }
 
+func TestClient_DeleteSavedProxyResponses_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	cases := map[string]struct {
+		Before func(*testing.T, *mbgo.Client)
+		After  func(*testing.T, *mbgo.Client)
+		Port   int
+
+		// output expectations
+		Expected *mbgo.Imposter
+		Err      error
+	}{
+		"should error if an imposter does not exist on the specified port": {
+			Port: 8080,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Err: errors.New("no such resource: Try POSTing to /imposters first?"),
+		},
+		"should return the expected imposter if it exists on the specified port": {
+			Port: 8080,
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8080,
+					Proto: "tcp",
+					Name:  "delete_saved_proxy_responses_test",
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Delete(newContext(time.Second), 8080, false)
+				assert.MustOk(t, err)
+			},
+			Expected: &mbgo.Imposter{
+				Port:  8080,
+				Proto: "tcp",
+				Name:  "delete_saved_proxy_responses_test",
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			if c.Before != nil {
+				c.Before(t, mb)
+			}
+
+			actual, err := mb.DeleteSavedProxyResponses(newContext(time.Second), c.Port)
+			if c.Err != nil {
+				assert.EqualError(t, c.Err, err)
+			} else {
+				assert.Ok(t, err)
+			}
+			clearLinks(actual)
+			assert.Equals(t, c.Expected, actual)
+
+			if c.After != nil {
+				c.After(t, mb)
+			}
+		})
+	}
+}
+
+func TestClient_Ping_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	err := mb.Ping(newContext(time.Second))
+	assert.MustOk(t, err)
+}
+
 func TestClient_Config_Integration(t *testing.T) {
 	mb := newMountebankClient()
 
 	cfg, err := mb.Config(newContext(time.Second))
 	assert.MustOk(t, err)
 	assert.Equals(t, "2.1.2", cfg.Version)
+	assert.Equals(t, 2525, cfg.Options.Port)
+	assert.Equals(t, true, cfg.Options.AllowInjection)
+}
+
+func TestClient_StrictDecoding_Integration(t *testing.T) {
+	mb := newMountebankClient(mbgo.WithStrictDecoding())
+
+	cfg, err := mb.Config(newContext(time.Second))
+	assert.MustOk(t, err)
+	assert.Equals(t, 2525, cfg.Options.Port)
+
+	_, err = mb.Create(newContext(time.Second), mbgo.Imposter{
+		Port:           8080,
+		Proto:          "http",
+		Name:           "strict_decoding_test",
+		RecordRequests: true,
+	})
+	assert.MustOk(t, err)
+
+	imp, err := mb.Imposter(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+	assert.Equals(t, "strict_decoding_test", imp.Name)
+
+	_, err = mb.Delete(newContext(time.Second), 8080, false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_Overwrite_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	cases := map[string]struct {
+		Before func(*testing.T, *mbgo.Client)
+		After  func(*testing.T, *mbgo.Client)
+		Input  []mbgo.Imposter
+
+		// output expectations
+		Expected []mbgo.Imposter
+		Err      error
+	}{
+		"should error if an invalid protocol is provided": {
+			Input: []mbgo.Imposter{
+				{
+					Proto: "udp",
+					Port:  8080,
+				},
+			},
+			Err: errors.New("bad data: the udp protocol is not yet supported"),
+		},
+		"should replace all registered imposters with the provided imposters": {
+			Before: func(t *testing.T, mb *mbgo.Client) {
+				_, err := mb.Create(newContext(time.Second), mbgo.Imposter{
+					Port:  8081,
+					Proto: "tcp",
+					Name:  "overwrite_test_removed",
+				})
+				assert.MustOk(t, err)
+			},
+			After: func(t *testing.T, mb *mbgo.Client) {
+				imps, err := mb.Imposters(newContext(time.Second), false)
+				assert.MustOk(t, err)
+				clearAllLinks(imps)
+				assert.Equals(t, []mbgo.Imposter{
+					{
+						Port:  8080,
+						Proto: "http",
+					},
+				}, imps)
+
+				_, err = mb.DeleteAll(newContext(time.Second), false)
+				assert.MustOk(t, err)
+			},
+			Input: []mbgo.Imposter{
+				{
+					Port:  8080,
+					Proto: "http",
+					Name:  "overwrite_test",
+				},
+			},
+			Expected: []mbgo.Imposter{
+				{
+					Port:  8080,
+					Proto: "http",
+					Name:  "overwrite_test",
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			if c.Before != nil {
+				c.Before(t, mb)
+			}
+
+			actual, err := mb.Overwrite(newContext(time.Second), c.Input)
+			if c.Err != nil {
+				assert.EqualError(t, c.Err, err)
+			} else {
+				assert.Ok(t, err)
+			}
+			clearAllLinks(actual)
+			assert.Equals(t, c.Expected, actual)
+
+			if c.After != nil {
+				c.After(t, mb)
+			}
+		})
+	}
 }
 
 func TestClient_Imposters_Integration(t *testing.T) {
@@ -1291,10 +2338,11 @@ func TestClient_Imposters_Integration(t *testing.T) {
 
 			actual, err := mb.Imposters(newContext(time.Second), c.Replay)
 			if c.Err != nil {
-				assert.Equals(t, c.Err, err)
+				assert.EqualError(t, c.Err, err)
 			} else {
 				assert.Ok(t, err)
 			}
+			clearAllLinks(actual)
 			assert.Equals(t, c.Expected, actual)
 
 			if c.After != nil {
@@ -1303,3 +2351,148 @@ func TestClient_Imposters_Integration(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ImpostersReplayable_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+
+	imps := []mbgo.Imposter{
+		{
+			Port:  8080,
+			Proto: "tcp",
+			Name:  "imposters_replayable_tcp_test",
+			Stubs: []mbgo.Stub{
+				{
+					Responses: []mbgo.Response{
+						{
+							Type: "is",
+							Value: mbgo.TCPResponse{
+								Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Port:  8081,
+			Proto: "http",
+			Name:  "imposters_replayable_http_test",
+		},
+	}
+	_, err = mb.Overwrite(newContext(time.Second), imps)
+	assert.MustOk(t, err)
+
+	actual, err := mb.ImpostersReplayable(newContext(time.Second), true)
+	assert.MustOk(t, err)
+	assert.Equals(t, []mbgo.Imposter{
+		{
+			Port:  8080,
+			Proto: "tcp",
+			Name:  "imposters_replayable_tcp_test",
+			Stubs: []mbgo.Stub{
+				{
+					Responses: []mbgo.Response{
+						{
+							Type: "is",
+							Value: &mbgo.TCPResponse{
+								Data: "Z2l0aHViLmNvbS9zZW5zZXllaW8vbWJnbw==",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Port:  8081,
+			Proto: "http",
+			Name:  "imposters_replayable_http_test",
+		},
+	}, actual)
+
+	_, err = mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+}
+
+func TestClient_ImpostersReplayable_RemoveProxies_Integration(t *testing.T) {
+	mb := newMountebankClient()
+
+	_, err := mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+
+	isStub := mbgo.Stub{
+		Predicates: []mbgo.Predicate{
+			{
+				Operator: "equals",
+				Request: mbgo.HTTPRequest{
+					Path: "/local",
+				},
+			},
+		},
+		Responses: []mbgo.Response{
+			{
+				Type: "is",
+				Value: mbgo.HTTPResponse{
+					StatusCode: http.StatusOK,
+				},
+			},
+		},
+	}
+	proxyStub := mbgo.Stub{
+		Responses: []mbgo.Response{
+			{
+				Type: "proxy",
+				Value: mbgo.Proxy{
+					To:   "http://localhost:8082",
+					Mode: mbgo.ProxyOnce,
+				},
+			},
+		},
+	}
+	_, err = mb.Overwrite(newContext(time.Second), []mbgo.Imposter{
+		{
+			Port:  8081,
+			Proto: "http",
+			Name:  "imposters_replayable_proxy_test",
+			Stubs: []mbgo.Stub{isStub, proxyStub},
+		},
+	})
+	assert.MustOk(t, err)
+
+	// countProxies returns the number of proxy responses of the imposters
+	countProxies := func(imps []mbgo.Imposter) int {
+		var n int
+		for _, imp := range imps {
+			for _, s := range imp.Stubs {
+				for _, r := range s.Responses {
+					if r.Type == "proxy" {
+						n++
+					}
+				}
+			}
+		}
+		return n
+	}
+
+	kept, err := mb.ImpostersReplayable(newContext(time.Second), false)
+	assert.MustOk(t, err)
+	assert.Equals(t, 1, countProxies(kept))
+
+	removed, err := mb.ImpostersReplayable(newContext(time.Second), true)
+	assert.MustOk(t, err)
+	assert.Equals(t, 1, len(removed))
+	assert.Equals(t, 0, countProxies(removed))
+	assert.Equals(t, []mbgo.Response{
+		{
+			Type: "is",
+			Value: &mbgo.HTTPResponse{
+				StatusCode: http.StatusOK,
+			},
+		},
+	}, removed[0].Stubs[0].Responses)
+
+	_, err = mb.DeleteAll(newContext(time.Second), false)
+	assert.MustOk(t, err)
+}
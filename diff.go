@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// DiffImposters returns the differences between the Imposters a and b as
+// human-readable strings, such as `stubs[0].responses[0].is.statusCode: 200 != 201`,
+// or nil if they are equal, such as to compare a created Imposter to the one
+// retrieved from mountebank in a test. The Imposters are compared by their JSON
+// encoding, so that the fields are named as in mountebank and equal values of
+// different Go types, such as an HTTPResponse and an *HTTPResponse, are equal.
+//
+// The fields that change as an Imposter is used are ignored; that is its
+// RequestCount, Requests and Links, as well as the Matches and Links of its Stubs.
+func DiffImposters(a, b Imposter) []string {
+	va, err := diffValue(a)
+	if err != nil {
+		return []string{fmt.Sprintf("cannot compare imposters: %v", err)}
+	}
+	vb, err := diffValue(b)
+	if err != nil {
+		return []string{fmt.Sprintf("cannot compare imposters: %v", err)}
+	}
+
+	var diffs []string
+	diffJSON("", va, vb, &diffs)
+	return diffs
+}
+
+// diffValue returns the decoded JSON encoding of the Imposter compared by
+// DiffImposters, without the fields it ignores.
+func diffValue(imp Imposter) (interface{}, error) {
+	imp.RequestCount = 0
+	imp.Requests = nil
+	imp.Links = nil
+	if imp.Stubs != nil {
+		stubs := make([]Stub, len(imp.Stubs))
+		for i, s := range imp.Stubs {
+			s.Matches = nil
+			s.Links = nil
+			stubs[i] = s
+		}
+		imp.Stubs = stubs
+	}
+
+	b, err := json.Marshal(imp)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep numbers as json.Number values so that large integers, such as
+	// 64-bit IDs in a JSON body, are not rounded to equal float64 values
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	err = dec.Decode(&v)
+	return v, err
+}
+
+// diffJSON appends the differences between the decoded JSON values a and b
+// at the given path to diffs, comparing objects and arrays element-wise.
+func diffJSON(path string, a, b interface{}, diffs *[]string) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			ae, aok := av[k]
+			be, bok := bv[k]
+			if !aok || !bok {
+				*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", p, formatDiffValue(ae, aok), formatDiffValue(be, bok)))
+				continue
+			}
+			diffJSON(p, ae, be, diffs)
+		}
+		return
+
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(av)
+		if len(bv) > n {
+			n = len(bv)
+		}
+
+		for i := 0; i < n; i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if i >= len(av) || i >= len(bv) {
+				*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s",
+					p, formatDiffValue(elem(av, i)), formatDiffValue(elem(bv, i))))
+				continue
+			}
+			diffJSON(p, av[i], bv[i], diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", path, formatDiffValue(a, true), formatDiffValue(b, true)))
+	}
+}
+
+// elem returns the element of vs at index i and whether it exists.
+func elem(vs []interface{}, i int) (interface{}, bool) {
+	if i < len(vs) {
+		return vs[i], true
+	}
+	return nil, false
+}
+
+// formatDiffValue formats the decoded JSON value v as JSON, or as "<none>"
+// if it does not exist.
+func formatDiffValue(v interface{}, ok bool) string {
+	if !ok {
+		return "<none>"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
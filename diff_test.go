@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ogbofjnr/mbgo"
+	"github.com/ogbofjnr/mbgo/internal/assert"
+)
+
+func TestDiffImposters(t *testing.T) {
+	imposter := func(status int, responses int) mbgo.Imposter {
+		imp := mbgo.Imposter{
+			Port:           8080,
+			Proto:          "http",
+			Name:           "diff_test",
+			RecordRequests: true,
+			AllowCORS:      true,
+			Stubs: []mbgo.Stub{
+				{
+					Predicates: []mbgo.Predicate{
+						{
+							Operator: mbgo.PredicateEquals,
+							Request: mbgo.HTTPRequest{
+								Method: http.MethodGet,
+							},
+						},
+					},
+				},
+			},
+		}
+		for i := 0; i < responses; i++ {
+			imp.Stubs[0].Responses = append(imp.Stubs[0].Responses, mbgo.Response{
+				Type: "is",
+				Value: mbgo.HTTPResponse{
+					StatusCode: status,
+				},
+			})
+		}
+		return imp
+	}
+
+	// retrieved is the imposter as decoded from mountebank after use
+	var retrieved mbgo.Imposter
+	err := json.Unmarshal([]byte(`{
+		"protocol": "http",
+		"port": 8080,
+		"numberOfRequests": 2,
+		"name": "diff_test",
+		"recordRequests": true,
+		"allowCORS": true,
+		"requests": [
+			{"requestFrom": "127.0.0.1:58112", "method": "GET", "path": "/", "query": {}, "headers": {}, "timestamp": "2021-03-04T05:06:07.089Z"},
+			{"requestFrom": "127.0.0.1:58114", "method": "GET", "path": "/", "query": {}, "headers": {}, "timestamp": "2021-03-04T05:06:08.089Z"}
+		],
+		"stubs": [
+			{
+				"predicates": [{"equals": {"method": "GET"}}],
+				"responses": [{"is": {"statusCode": 200}}],
+				"matches": [{"timestamp": "2021-03-04T05:06:07.089Z", "request": {"method": "GET", "path": "/"}, "response": {"statusCode": 200}}],
+				"_links": {"self": {"href": "http://localhost:2525/imposters/8080/stubs/0"}}
+			}
+		],
+		"_links": {
+			"self": {"href": "http://localhost:2525/imposters/8080"},
+			"stubs": {"href": "http://localhost:2525/imposters/8080/stubs"}
+		}
+	}`), &retrieved)
+	assert.MustOk(t, err)
+
+	renamed := imposter(http.StatusOK, 1)
+	renamed.Name = "other"
+
+	unnamed := imposter(http.StatusOK, 1)
+	unnamed.Name = ""
+
+	large := func(id int64) mbgo.Imposter {
+		imp := imposter(http.StatusOK, 1)
+		imp.Stubs[0].Responses[0].Value = mbgo.HTTPResponse{
+			StatusCode: http.StatusOK,
+			Body:       map[string]interface{}{"id": id},
+		}
+		return imp
+	}
+
+	cases := map[string]struct {
+		a, b mbgo.Imposter
+		want []string
+	}{
+		"returns nil for equal imposters": {
+			a: imposter(http.StatusOK, 1),
+			b: imposter(http.StatusOK, 1),
+		},
+		"ignores volatile fields and the Go types of equal values": {
+			a: imposter(http.StatusOK, 1),
+			b: retrieved,
+		},
+		"returns the differences of fields": {
+			a: imposter(http.StatusOK, 1),
+			b: renamed,
+			want: []string{
+				`name: "diff_test" != "other"`,
+			},
+		},
+		"returns the missing fields of objects": {
+			a: unnamed,
+			b: imposter(http.StatusOK, 1),
+			want: []string{
+				`name: <none> != "diff_test"`,
+			},
+		},
+		"returns the differences of nested fields": {
+			a: imposter(http.StatusOK, 1),
+			b: imposter(http.StatusCreated, 1),
+			want: []string{
+				`stubs[0].responses[0].is.statusCode: 200 != 201`,
+			},
+		},
+		"returns the missing elements of arrays": {
+			a: imposter(http.StatusOK, 1),
+			b: imposter(http.StatusOK, 2),
+			want: []string{
+				`stubs[0].responses[1]: <none> != {"is":{"statusCode":200}}`,
+			},
+		},
+		"returns the differences of large numbers": {
+			a: large(9007199254740993),
+			b: large(9007199254740992),
+			want: []string{
+				`stubs[0].responses[0].is.body.id: 9007199254740993 != 9007199254740992`,
+			},
+		},
+		"returns the differences of null values": {
+			a: imposter(http.StatusOK, 0),
+			b: imposter(http.StatusOK, 1),
+			want: []string{
+				`stubs[0].responses: null != [{"is":{"statusCode":200}}]`,
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equals(t, c.want, mbgo.DiffImposters(c.a, c.b))
+		})
+	}
+}
+
+func TestDiffImposters_Error(t *testing.T) {
+	t.Parallel()
+
+	a := mbgo.Imposter{Port: 8080, Proto: "http"}
+	b := mbgo.Imposter{
+		Port:  8080,
+		Proto: "http",
+		Stubs: []mbgo.Stub{
+			{
+				Responses: []mbgo.Response{
+					{
+						Type: "proxy",
+						Value: mbgo.Proxy{
+							To:   "http://localhost:8081",
+							Mode: "proxyNever",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	diffs := mbgo.DiffImposters(a, b)
+	assert.Equals(t, 1, len(diffs))
+	assert.Equals(t, true, strings.HasPrefix(diffs[0], "cannot compare imposters: "))
+	assert.Equals(t, true, strings.HasSuffix(diffs[0], `invalid proxy mode: "proxyNever"`))
+}
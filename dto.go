@@ -4,6 +4,7 @@
 package mbgo
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -59,19 +60,41 @@ func fromMapValues(q map[string]interface{}) (map[string][]string, error) {
 			for i, elem := range typ {
 				s, ok := elem.(string)
 				if !ok {
-					return nil, errors.New("invalid query key array subtype")
+					return nil, fmt.Errorf("invalid array subtype of key %q: %#v", k, elem)
 				}
 				ss[i] = s
 			}
 			out[k] = ss
 		default:
-			return nil, fmt.Errorf("invalid query key type: %#v", typ)
+			return nil, fmt.Errorf("invalid type of key %q: %#v", k, typ)
 		}
 	}
 
 	return out, nil
 }
 
+// numberValue is an arbitrary JSON value decoded with its numbers kept as
+// json.Number values rather than float64, so that large integers, such as
+// 64-bit IDs in a JSON body, do not lose precision.
+type numberValue struct {
+	v interface{}
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (n *numberValue) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(&n.v)
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (fn JSFunction) MarshalJSON() ([]byte, error) {
+	if fn == "" {
+		return nil, errors.New("injected JavaScript function is empty")
+	}
+	return json.Marshal(string(fn))
+}
+
 type httpRequestDTO struct {
 	RequestFrom string                 `json:"requestFrom,omitempty"`
 	Method      string                 `json:"method,omitempty"`
@@ -79,6 +102,7 @@ type httpRequestDTO struct {
 	Query       map[string]interface{} `json:"query,omitempty"`
 	Headers     map[string]interface{} `json:"headers,omitempty"`
 	Body        interface{}            `json:"body,omitempty"`
+	Form        map[string]interface{} `json:"form,omitempty"`
 	Timestamp   string                 `json:"timestamp,omitempty"`
 }
 
@@ -91,6 +115,7 @@ func (r HTTPRequest) MarshalJSON() ([]byte, error) {
 		Query:       toMapValues(r.Query),
 		Headers:     toMapValues(r.Headers),
 		Body:        r.Body,
+		Form:        toMapValues(r.Form),
 		Timestamp:   r.Timestamp,
 	}
 	if r.RequestFrom != nil {
@@ -101,7 +126,8 @@ func (r HTTPRequest) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON satisfies the json.Unmarshaler interface.
 func (r *HTTPRequest) UnmarshalJSON(b []byte) error {
-	var v httpRequestDTO
+	var body numberValue
+	v := httpRequestDTO{Body: &body} // decode the body into body.v
 	err := json.Unmarshal(b, &v)
 	if err != nil {
 		return err
@@ -110,20 +136,24 @@ func (r *HTTPRequest) UnmarshalJSON(b []byte) error {
 	if v.RequestFrom != "" {
 		r.RequestFrom, err = parseClientSocket(v.RequestFrom)
 		if err != nil {
-			return nil
+			return err
 		}
 	}
 	r.Method = v.Method
 	r.Path = v.Path
 	r.Query, err = fromMapValues(v.Query)
 	if err != nil {
-		return nil
+		return err
 	}
 	r.Headers, err = fromMapValues(v.Headers)
 	if err != nil {
-		return nil
+		return err
+	}
+	r.Body = body.v
+	r.Form, err = fromMapValues(v.Form)
+	if err != nil {
+		return err
 	}
-	r.Body = v.Body
 	r.Timestamp = v.Timestamp
 
 	return nil
@@ -148,7 +178,8 @@ func (r HTTPResponse) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON satisfies the json.Unmarshaler interface.
 func (r *HTTPResponse) UnmarshalJSON(b []byte) error {
-	var v httpResponseDTO
+	var body numberValue
+	v := httpResponseDTO{Body: &body} // decode the body into body.v
 	err := json.Unmarshal(b, &v)
 	if err != nil {
 		return err
@@ -159,7 +190,7 @@ func (r *HTTPResponse) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
-	r.Body = v.Body
+	r.Body = body.v
 	r.Mode = v.Mode
 
 	return nil
@@ -167,18 +198,24 @@ func (r *HTTPResponse) UnmarshalJSON(b []byte) error {
 
 type tcpRequestDTO struct {
 	RequestFrom string `json:"requestFrom,omitempty"`
+	IP          string `json:"ip,omitempty"`
 	Data        string `json:"data,omitempty"`
+	Timestamp   string `json:"timestamp,omitempty"`
 }
 
 // MarshalJSON satisfies the json.Marshaler interface.
 func (r TCPRequest) MarshalJSON() ([]byte, error) {
 	dto := tcpRequestDTO{
-		RequestFrom: "",
+		RequestFrom: r.RequestFromAddr,
 		Data:        r.Data,
+		Timestamp:   r.Timestamp,
 	}
-	if r.RequestFrom != nil {
+	if dto.RequestFrom == "" && r.RequestFrom != nil {
 		dto.RequestFrom = r.RequestFrom.String()
 	}
+	if r.IP != nil {
+		dto.IP = r.IP.String()
+	}
 	return json.Marshal(dto)
 }
 
@@ -195,8 +232,16 @@ func (r *TCPRequest) UnmarshalJSON(b []byte) error {
 		if err != nil {
 			return err
 		}
+		r.RequestFromAddr = v.RequestFrom
+	}
+	if v.IP != "" {
+		r.IP = net.ParseIP(v.IP)
+		if r.IP == nil {
+			return fmt.Errorf("invalid IP address: %s", v.IP)
+		}
 	}
 	r.Data = v.Data
+	r.Timestamp = v.Timestamp
 
 	return err
 }
@@ -223,6 +268,166 @@ func (r *TCPResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+type grpcRequestDTO struct {
+	Path     string                 `json:"path,omitempty"`
+	Value    interface{}            `json:"value,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (r GRPCRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(grpcRequestDTO(r))
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (r *GRPCRequest) UnmarshalJSON(b []byte) error {
+	var value numberValue
+	v := grpcRequestDTO{Value: &value} // decode the value into value.v
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+
+	r.Path = v.Path
+	r.Value = value.v
+	r.Metadata = v.Metadata
+
+	return nil
+}
+
+type grpcResponseDTO struct {
+	Value    interface{}            `json:"value,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (r GRPCResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(grpcResponseDTO(r))
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (r *GRPCResponse) UnmarshalJSON(b []byte) error {
+	var value numberValue
+	v := grpcResponseDTO{Value: &value} // decode the value into value.v
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+
+	r.Value = value.v
+	r.Metadata = v.Metadata
+
+	return nil
+}
+
+type proxyDTO struct {
+	To                  string               `json:"to"`
+	Mode                string               `json:"mode,omitempty"`
+	PredicateGenerators []PredicateGenerator `json:"predicateGenerators,omitempty"`
+	InjectHeaders       map[string]string    `json:"injectHeaders,omitempty"`
+	AddWaitBehavior     bool                 `json:"addWaitBehavior,omitempty"`
+	AddDecorateBehavior string               `json:"addDecorateBehavior,omitempty"`
+	Cert                string               `json:"cert,omitempty"`
+	Key                 string               `json:"key,omitempty"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (p Proxy) MarshalJSON() ([]byte, error) {
+	switch p.Mode {
+	case "", ProxyOnce, ProxyAlways, ProxyTransparent:
+	default:
+		return nil, fmt.Errorf("invalid proxy mode: %q", p.Mode)
+	}
+
+	return json.Marshal(proxyDTO{
+		To:                  p.To,
+		Mode:                p.Mode,
+		PredicateGenerators: p.PredicateGenerators,
+		InjectHeaders:       p.InjectHeaders,
+		AddWaitBehavior:     p.AddWaitBehavior,
+		AddDecorateBehavior: p.AddDecorateBehavior,
+		Cert:                p.Cert,
+		Key:                 p.Key,
+	})
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (p *Proxy) UnmarshalJSON(b []byte) error {
+	var v proxyDTO
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+
+	p.To = v.To
+	p.Mode = v.Mode
+	p.PredicateGenerators = v.PredicateGenerators
+	p.InjectHeaders = v.InjectHeaders
+	p.AddWaitBehavior = v.AddWaitBehavior
+	p.AddDecorateBehavior = v.AddDecorateBehavior
+	p.Cert = v.Cert
+	p.Key = v.Key
+
+	return nil
+}
+
+type smtpRequestDTO struct {
+	RequestFrom string         `json:"requestFrom,omitempty"`
+	From        EmailAddress   `json:"from"`
+	To          []EmailAddress `json:"to,omitempty"`
+	Cc          []EmailAddress `json:"cc,omitempty"`
+	Bcc         []EmailAddress `json:"bcc,omitempty"`
+	Subject     string         `json:"subject,omitempty"`
+	Text        string         `json:"text,omitempty"`
+	HTML        string         `json:"html,omitempty"`
+	Attachments []interface{}  `json:"attachments,omitempty"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (r SMTPRequest) MarshalJSON() ([]byte, error) {
+	dto := smtpRequestDTO{
+		RequestFrom: "",
+		From:        r.From,
+		To:          r.To,
+		Cc:          r.Cc,
+		Bcc:         r.Bcc,
+		Subject:     r.Subject,
+		Text:        r.Text,
+		HTML:        r.HTML,
+		Attachments: r.Attachments,
+	}
+	if r.RequestFrom != nil {
+		dto.RequestFrom = r.RequestFrom.String()
+	}
+	return json.Marshal(dto)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (r *SMTPRequest) UnmarshalJSON(b []byte) error {
+	var v smtpRequestDTO
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+
+	if v.RequestFrom != "" {
+		r.RequestFrom, err = parseClientSocket(v.RequestFrom)
+		if err != nil {
+			return err
+		}
+	}
+	r.From = v.From
+	r.To = v.To
+	r.Cc = v.Cc
+	r.Bcc = v.Bcc
+	r.Subject = v.Subject
+	r.Text = v.Text
+	r.HTML = v.HTML
+	r.Attachments = v.Attachments
+
+	return nil
+}
+
 const (
 	// Predicate parameter keys for internal use.
 	paramCaseSensitive = "caseSensitive"
@@ -261,7 +466,7 @@ func (p Predicate) MarshalJSON() ([]byte, error) {
 		}
 		dto[p.Operator] = b
 
-	case string:
+	case string, map[string]interface{}:
 		b, err := json.Marshal(t)
 		if err != nil {
 			return nil, err
@@ -281,6 +486,14 @@ func (p Predicate) MarshalJSON() ([]byte, error) {
 		dto[paramJSONPath] = b
 	}
 
+	if p.XPath != nil {
+		b, err := json.Marshal(p.XPath)
+		if err != nil {
+			return nil, err
+		}
+		dto[paramXPath] = b
+	}
+
 	if p.CaseSensitive {
 		b, err := json.Marshal(p.CaseSensitive)
 		if err != nil {
@@ -289,6 +502,14 @@ func (p Predicate) MarshalJSON() ([]byte, error) {
 		dto[paramCaseSensitive] = b
 	}
 
+	if p.Except != "" {
+		b, err := json.Marshal(p.Except)
+		if err != nil {
+			return nil, err
+		}
+		dto[paramExcept] = b
+	}
+
 	return json.Marshal(dto)
 }
 
@@ -316,9 +537,20 @@ func (p *Predicate) UnmarshalJSON(b []byte) error {
 		}
 		delete(dto, paramJSONPath)
 	}
-	// Ignore 'except' and 'xpath' parameters for now.
-	delete(dto, paramExcept)
-	delete(dto, paramXPath)
+	if b, ok := dto[paramXPath]; ok {
+		err = json.Unmarshal(b, &p.XPath)
+		if err != nil {
+			return err
+		}
+		delete(dto, paramXPath)
+	}
+	if b, ok := dto[paramExcept]; ok {
+		err = json.Unmarshal(b, &p.Except)
+		if err != nil {
+			return err
+		}
+		delete(dto, paramExcept)
+	}
 
 	if len(dto) < 1 {
 		return errors.New("predicate should only have a single operator")
@@ -337,6 +569,16 @@ func (p *Predicate) UnmarshalJSON(b []byte) error {
 			}
 			p.Request = js
 
+		// Interpret the request as a map of request fields to booleans, or
+		// nested maps of them, if the exists operator is used.
+		case "exists":
+			var fields map[string]interface{}
+			err = json.Unmarshal(b, &fields)
+			if err != nil {
+				return err
+			}
+			p.Request = fields
+
 		// Slice of predicates
 		case "and", "or":
 			var ps []Predicate
@@ -364,25 +606,105 @@ func (p *Predicate) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+type behaviorsDTO struct {
+	Wait           json.RawMessage  `json:"wait,omitempty"`
+	Decorate       string           `json:"decorate,omitempty"`
+	ShellTransform []string         `json:"shellTransform,omitempty"`
+	Copy           []CopyBehavior   `json:"copy,omitempty"`
+	Lookup         []LookupBehavior `json:"lookup,omitempty"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (b Behaviors) MarshalJSON() ([]byte, error) {
+	dto := behaviorsDTO{
+		Decorate:       b.Decorate,
+		ShellTransform: b.ShellTransform,
+		Copy:           b.Copy,
+		Lookup:         b.Lookup,
+	}
+
+	var err error
+	switch w := b.Wait.(type) {
+	case nil:
+	case int:
+		if w != 0 {
+			dto.Wait, err = json.Marshal(w)
+		}
+	case string:
+		if w != "" {
+			dto.Wait, err = json.Marshal(w)
+		}
+	default:
+		return nil, fmt.Errorf("invalid wait behavior type: %T", w)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(dto)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (b *Behaviors) UnmarshalJSON(data []byte) error {
+	var dto behaviorsDTO
+	err := json.Unmarshal(data, &dto)
+	if err != nil {
+		return err
+	}
+
+	b.Wait = nil
+	if len(dto.Wait) > 0 {
+		// Interpret the wait behavior as a JavaScript function if it is
+		// a string, and as a number of milliseconds otherwise.
+		if dto.Wait[0] == '"' {
+			var js string
+			err = json.Unmarshal(dto.Wait, &js)
+			b.Wait = js
+		} else {
+			var ms int
+			err = json.Unmarshal(dto.Wait, &ms)
+			b.Wait = ms
+		}
+		if err != nil {
+			return err
+		}
+	}
+	b.Decorate = dto.Decorate
+	b.ShellTransform = dto.ShellTransform
+	b.Copy = dto.Copy
+	b.Lookup = dto.Lookup
+
+	return nil
+}
+
 const (
 	keyBehaviors = "_behaviors"
+	keyRepeat    = "repeat"
 )
 
 // MarshalJSON satisfies the json.Marshaler interface.
 func (r Response) MarshalJSON() ([]byte, error) {
 	dto := make(map[string]json.RawMessage)
 
-	m, ok := r.Value.(json.Marshaler)
-	if !ok {
-		return nil, errors.New("response value must implement json.Marshaler")
-	}
+	// marshal value based on type
+	switch t := r.Value.(type) {
+	case json.Marshaler:
+		b, err := t.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		dto[r.Type] = b
 
-	b, err := m.MarshalJSON()
-	if err != nil {
-		return nil, err
-	}
+	case string:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		dto[r.Type] = b
 
-	dto[r.Type] = b
+	default:
+		return nil, errors.New("response value must implement json.Marshaler or be a string")
+	}
 
 	if r.Behaviors != nil {
 		behaviors, err := json.Marshal(r.Behaviors)
@@ -392,6 +714,14 @@ func (r Response) MarshalJSON() ([]byte, error) {
 		dto[keyBehaviors] = behaviors
 	}
 
+	if r.Repeat > 0 {
+		repeat, err := json.Marshal(r.Repeat)
+		if err != nil {
+			return nil, err
+		}
+		dto[keyRepeat] = repeat
+	}
+
 	return json.Marshal(dto)
 }
 
@@ -403,27 +733,51 @@ func (r *Response) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
-	// Handle and delete behaviors from the DTO map before we check the
-	// type so that we can enforce only one type exists in the map.
+	// Handle and delete behaviors and repeat from the DTO map before we
+	// check the type so that we can enforce only one type exists in the map.
 	if b, ok := dto[keyBehaviors]; ok {
-		err = json.Unmarshal(b, r.Behaviors)
+		err = json.Unmarshal(b, &r.Behaviors)
 		if err != nil {
 			return err
 		}
 		delete(dto, keyBehaviors)
 	}
+	if b, ok := dto[keyRepeat]; ok {
+		err = json.Unmarshal(b, &r.Repeat)
+		if err != nil {
+			return err
+		}
+		delete(dto, keyRepeat)
+	}
 
 	for key, b := range dto {
 		r.Type = key
-		r.Value = b // defer unmarshaling until protocol is known
+
+		switch key {
+		// Interpret the value as a string containing either JavaScript or
+		// the fault name if the inject or fault types are used.
+		case "inject", "fault":
+			var v string
+			err = json.Unmarshal(b, &v)
+			if err != nil {
+				return err
+			}
+			r.Value = v
+
+		// Otherwise we have a response object.
+		default:
+			r.Value = b // defer unmarshaling until protocol is known
+		}
 	}
 
 	return nil
 }
 
 type stubDTO struct {
-	Predicates []Predicate `json:"predicates,omitempty"`
-	Responses  []Response  `json:"responses"`
+	Predicates []Predicate     `json:"predicates,omitempty"`
+	Responses  []Response      `json:"responses"`
+	Matches    []Match         `json:"matches,omitempty"`
+	Links      map[string]Link `json:"_links,omitempty"`
 }
 
 // MarshalJSON satisfies the json.Marshaler interface.
@@ -441,18 +795,76 @@ func (s *Stub) UnmarshalJSON(b []byte) error {
 
 	s.Predicates = dto.Predicates
 	s.Responses = dto.Responses
+	s.Matches = dto.Matches
+	s.Links = dto.Links
+
+	return nil
+}
+
+type matchDTO struct {
+	Timestamp string          `json:"timestamp,omitempty"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Response  json.RawMessage `json:"response,omitempty"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (m Match) MarshalJSON() ([]byte, error) {
+	dto := matchDTO{
+		Timestamp: m.Timestamp,
+	}
+	if m.Request != nil {
+		b, err := json.Marshal(m.Request)
+		if err != nil {
+			return nil, err
+		}
+		dto.Request = b
+	}
+	if m.Response != nil {
+		b, err := json.Marshal(m.Response)
+		if err != nil {
+			return nil, err
+		}
+		dto.Response = b
+	}
+	return json.Marshal(dto)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (m *Match) UnmarshalJSON(b []byte) error {
+	var dto matchDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+
+	m.Timestamp = dto.Timestamp
+	// defer unmarshaling until protocol is known
+	if dto.Request != nil {
+		m.Request = dto.Request
+	}
+	if dto.Response != nil {
+		m.Response = dto.Response
+	}
 
 	return nil
 }
 
 type imposterRequestDTO struct {
-	Proto           string            `json:"protocol"`
-	Port            int               `json:"port,omitempty"`
-	Name            string            `json:"name,omitempty"`
-	RecordRequests  bool              `json:"recordRequests,omitempty"`
-	AllowCORS       bool              `json:"allowCORS,omitempty"`
-	DefaultResponse json.RawMessage   `json:"defaultResponse,omitempty"`
-	Stubs           []json.RawMessage `json:"stubs,omitempty"`
+	Proto                string                 `json:"protocol"`
+	Port                 int                    `json:"port,omitempty"`
+	Name                 string                 `json:"name,omitempty"`
+	RecordRequests       bool                   `json:"recordRequests,omitempty"`
+	AllowCORS            bool                   `json:"allowCORS,omitempty"`
+	DefaultResponse      json.RawMessage        `json:"defaultResponse,omitempty"`
+	Stubs                []json.RawMessage      `json:"stubs,omitempty"`
+	Key                  string                 `json:"key,omitempty"`
+	Cert                 string                 `json:"cert,omitempty"`
+	MutualAuth           bool                   `json:"mutualAuth,omitempty"`
+	Ciphers              string                 `json:"ciphers,omitempty"`
+	Mode                 string                 `json:"mode,omitempty"`
+	EndOfRequestResolver *Resolver              `json:"endOfRequestResolver,omitempty"`
+	Protofile            string                 `json:"protofile,omitempty"`
+	Services             map[string]GRPCService `json:"services,omitempty"`
 }
 
 // MarshalJSON satisfies the json.Marshaler interface.
@@ -462,10 +874,26 @@ func (imp Imposter) MarshalJSON() ([]byte, error) {
 		Port:            imp.Port,
 		Name:            imp.Name,
 		RecordRequests:  imp.RecordRequests,
-		AllowCORS:       imp.AllowCORS,
 		DefaultResponse: nil,
 		Stubs:           nil,
 	}
+	if imp.Proto == "http" || imp.Proto == "https" {
+		dto.AllowCORS = imp.AllowCORS
+	}
+	if imp.Proto == "https" {
+		dto.Key = imp.Key
+		dto.Cert = imp.Cert
+		dto.MutualAuth = imp.MutualAuth
+		dto.Ciphers = imp.Ciphers
+	}
+	if imp.Proto == "tcp" {
+		dto.Mode = imp.Mode
+		dto.EndOfRequestResolver = imp.EndOfRequestResolver
+	}
+	if !isBuiltinProtocol(imp.Proto) {
+		dto.Protofile = imp.Protofile
+		dto.Services = imp.Services
+	}
 	if imp.DefaultResponse != nil {
 		jm, ok := imp.DefaultResponse.(json.Marshaler)
 		if !ok {
@@ -487,27 +915,65 @@ func (imp Imposter) MarshalJSON() ([]byte, error) {
 			dto.Stubs[i] = b
 		}
 	}
-	return json.Marshal(dto)
+
+	b, err := json.Marshal(dto)
+	if err != nil || isBuiltinProtocol(imp.Proto) || len(imp.Options) == 0 {
+		return b, err
+	}
+
+	// merge the options of a custom protocol with the other fields,
+	// which take precedence over any options of the same name
+	var known map[string]json.RawMessage
+	if err = json.Unmarshal(b, &known); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{}, len(imp.Options)+len(known))
+	for k, v := range imp.Options {
+		fields[k] = v
+	}
+	for k, v := range known {
+		fields[k] = v
+	}
+	return json.Marshal(fields)
 }
 
 type imposterResponseDTO struct {
-	Port         int               `json:"port"`
-	Proto        string            `json:"protocol"`
-	Name         string            `json:"name,omitempty"`
-	RequestCount int               `json:"numberOfRequests,omitempty"`
-	Stubs        []json.RawMessage `json:"stubs,omitempty"`
-	Requests     []json.RawMessage `json:"requests,omitempty"`
+	Port                 int                    `json:"port"`
+	Proto                string                 `json:"protocol"`
+	Name                 string                 `json:"name,omitempty"`
+	RecordRequests       bool                   `json:"recordRequests,omitempty"`
+	AllowCORS            bool                   `json:"allowCORS,omitempty"`
+	RequestCount         int                    `json:"numberOfRequests,omitempty"`
+	Stubs                []json.RawMessage      `json:"stubs,omitempty"`
+	Requests             []json.RawMessage      `json:"requests,omitempty"`
+	Links                map[string]Link        `json:"_links,omitempty"`
+	DefaultResponse      json.RawMessage        `json:"defaultResponse,omitempty"`
+	Key                  string                 `json:"key,omitempty"`
+	Cert                 string                 `json:"cert,omitempty"`
+	MutualAuth           bool                   `json:"mutualAuth,omitempty"`
+	Ciphers              string                 `json:"ciphers,omitempty"`
+	Mode                 string                 `json:"mode,omitempty"`
+	EndOfRequestResolver *Resolver              `json:"endOfRequestResolver,omitempty"`
+	Protofile            string                 `json:"protofile,omitempty"`
+	Services             map[string]GRPCService `json:"services,omitempty"`
 }
 
 func getRequestUnmarshaler(proto string) (json.Unmarshaler, error) {
 	var um json.Unmarshaler
 	switch proto {
-	case "http":
+	case "http", "https":
 		um = &HTTPRequest{}
 	case "tcp":
 		um = &TCPRequest{}
+	case "smtp":
+		um = &SMTPRequest{}
+	case protoGRPC:
+		um = &GRPCRequest{}
 	default:
-		return nil, fmt.Errorf("unsupported protocol: %s", proto)
+		if !isCustomProtocol(proto) {
+			return nil, fmt.Errorf("unsupported protocol: %s", proto)
+		}
+		um = &json.RawMessage{}
 	}
 	return um, nil
 }
@@ -528,6 +994,8 @@ func unmarshalPredicateRecurse(proto string, p *Predicate) error {
 		if err := unmarshalPredicateRecurse(proto, &v); err != nil {
 			return err
 		}
+		p.Request = v
+
 	case []Predicate:
 		for i := range v {
 			if err := unmarshalPredicateRecurse(proto, &v[i]); err != nil {
@@ -541,16 +1009,57 @@ func unmarshalPredicateRecurse(proto string, p *Predicate) error {
 func getResponseUnmarshaler(proto string) (json.Unmarshaler, error) {
 	var um json.Unmarshaler
 	switch proto {
-	case "http":
+	case "http", "https":
 		um = &HTTPResponse{}
 	case "tcp":
 		um = &TCPResponse{}
+	case protoGRPC:
+		um = &GRPCResponse{}
 	default:
-		return nil, fmt.Errorf("unsupported protocol: %s", proto)
+		if !isCustomProtocol(proto) {
+			return nil, fmt.Errorf("unsupported protocol: %s", proto)
+		}
+		um = &json.RawMessage{}
 	}
 	return um, nil
 }
 
+// isBuiltinProtocol reports whether the protocol is built into mountebank,
+// rather than a custom protocol implemented by a plugin.
+func isBuiltinProtocol(proto string) bool {
+	switch proto {
+	case "http", "https", "tcp", "smtp":
+		return true
+	}
+	return false
+}
+
+// isCustomProtocol reports whether the protocol is the "grpc" protocol or
+// has been registered as a custom protocol using RegisterProtocol.
+func isCustomProtocol(proto string) bool {
+	if proto == protoGRPC {
+		return true
+	}
+	_, ok := customProtocols.Load(proto)
+	return ok
+}
+
+// imposterResponseFields are the names of all the fields of
+// imposterResponseDTO, which are excluded from the Options of a
+// custom protocol whether or not they are used by the protocol.
+var imposterResponseFields = jsonFieldNames(reflect.TypeOf(imposterResponseDTO{}))
+
+// jsonFieldNames returns the JSON names of the fields of the struct type.
+func jsonFieldNames(typ reflect.Type) []string {
+	names := make([]string, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		if name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]; name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // UnmarshalJSON satisfies the json.Unmarshaler interface.
 func (imp *Imposter) UnmarshalJSON(b []byte) error {
 	var dto imposterResponseDTO
@@ -562,7 +1071,50 @@ func (imp *Imposter) UnmarshalJSON(b []byte) error {
 	imp.Port = dto.Port
 	imp.Proto = dto.Proto
 	imp.Name = dto.Name
+	imp.RecordRequests = dto.RecordRequests
 	imp.RequestCount = dto.RequestCount
+	imp.Links = dto.Links
+	if imp.Proto == "http" || imp.Proto == "https" {
+		imp.AllowCORS = dto.AllowCORS
+	}
+	if imp.Proto == "https" {
+		imp.Key = dto.Key
+		imp.Cert = dto.Cert
+		imp.MutualAuth = dto.MutualAuth
+		imp.Ciphers = dto.Ciphers
+	}
+	if imp.Proto == "tcp" {
+		imp.Mode = dto.Mode
+		imp.EndOfRequestResolver = dto.EndOfRequestResolver
+	}
+
+	if isCustomProtocol(imp.Proto) {
+		imp.Protofile = dto.Protofile
+		imp.Services = dto.Services
+
+		var fields map[string]interface{}
+		if err = json.Unmarshal(b, &fields); err != nil {
+			return err
+		}
+		for _, k := range imposterResponseFields {
+			delete(fields, k)
+		}
+		if len(fields) > 0 {
+			imp.Options = fields
+		}
+	}
+
+	if len(dto.DefaultResponse) > 0 {
+		um, err := getResponseUnmarshaler(imp.Proto)
+		if err != nil {
+			return err
+		}
+		err = um.UnmarshalJSON(dto.DefaultResponse)
+		if err != nil {
+			return err
+		}
+		imp.DefaultResponse = um
+	}
 
 	if n := len(dto.Stubs); n > 0 {
 		imp.Stubs = make([]Stub, n)
@@ -582,6 +1134,34 @@ func (imp *Imposter) UnmarshalJSON(b []byte) error {
 
 			for i, r := range s.Responses {
 				if raw, ok := r.Value.(json.RawMessage); ok {
+					var um json.Unmarshaler = &Proxy{}
+					if r.Type != "proxy" {
+						um, err = getResponseUnmarshaler(imp.Proto)
+						if err != nil {
+							return err
+						}
+					}
+					err = um.UnmarshalJSON(raw)
+					if err != nil {
+						return err
+					}
+					s.Responses[i].Value = um
+				}
+			}
+
+			for i, m := range s.Matches {
+				if raw, ok := m.Request.(json.RawMessage); ok {
+					um, err := getRequestUnmarshaler(imp.Proto)
+					if err != nil {
+						return err
+					}
+					err = um.UnmarshalJSON(raw)
+					if err != nil {
+						return err
+					}
+					s.Matches[i].Request = um
+				}
+				if raw, ok := m.Response.(json.RawMessage); ok {
 					um, err := getResponseUnmarshaler(imp.Proto)
 					if err != nil {
 						return err
@@ -590,7 +1170,7 @@ func (imp *Imposter) UnmarshalJSON(b []byte) error {
 					if err != nil {
 						return err
 					}
-					s.Responses[i].Value = um
+					s.Matches[i].Response = um
 				}
 			}
 
@@ -615,3 +1195,101 @@ func (imp *Imposter) UnmarshalJSON(b []byte) error {
 
 	return nil
 }
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (o *ConfigOptions) UnmarshalJSON(b []byte) error {
+	// decode the known options using an alias without this method
+	type options ConfigOptions
+	var v options
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	for _, name := range jsonFieldNames(reflect.TypeOf(v)) {
+		delete(fields, name)
+	}
+	v.Other = nil
+	if len(fields) > 0 {
+		v.Other = fields
+	}
+
+	*o = ConfigOptions(v)
+	return nil
+}
+
+// expectDelim reads the next token from the decoder, returning an error if
+// it is not the expected JSON delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("unexpected JSON token, expected %s: %v", delim, tok)
+	}
+	return nil
+}
+
+// decodeHTTPRequestStream decodes the recorded requests of the JSON imposter
+// read by the decoder one at a time, calling fn with each of them in order
+// and stopping at the first error returned by fn. The other fields of the
+// imposter are skipped without being decoded into Go values. An error is
+// returned if the protocol of the imposter is not "http" or "https", or if
+// it is not known before its requests are read, as mountebank sends the
+// protocol first.
+func decodeHTTPRequestStream(dec *json.Decoder, fn func(HTTPRequest) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	var proto string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		switch key {
+		case "protocol":
+			if err = dec.Decode(&proto); err != nil {
+				return err
+			}
+			if proto != "http" && proto != "https" {
+				return fmt.Errorf("imposter protocol is not http or https: %q", proto)
+			}
+			continue
+		case "requests":
+			if proto == "" {
+				return errors.New("imposter protocol is unknown before its requests")
+			}
+		default:
+			var skip json.RawMessage
+			if err = dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for dec.More() {
+			var r HTTPRequest
+			if err = dec.Decode(&r); err != nil {
+				return err
+			}
+			if err = fn(r); err != nil {
+				return err
+			}
+		}
+		if err = expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrImposterNotFound is matched by an *APIError using errors.Is when the
+// mountebank API responds with a 404 Not Found status code, such as when
+// no Imposter exists on the requested port.
+var ErrImposterNotFound = errors.New("imposter not found")
+
+// APIError is returned by the Client whenever the mountebank API responds
+// with an unexpected status code, such as when an Imposter is rejected for
+// being invalid or its port is already in use.
+//
+// See more information about errors in mountebank at:
+// http://www.mbtest.org/docs/api/errors.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Errors are the errors returned in the response body.
+	Errors []ErrorDetail
+}
+
+// ErrorDetail represents a single error returned by the mountebank API.
+type ErrorDetail struct {
+	// Code is the error code, such as "bad data" or "resource conflict".
+	Code string `json:"code"`
+
+	// Message is the description of the error.
+	Message string `json:"message"`
+
+	// Source is the part of the request that caused the error, if any.
+	Source interface{} `json:"source,omitempty"`
+}
+
+// Error satisfies the error interface.
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("unexpected response status code: %d", e.StatusCode)
+	}
+	// Silently ignore all but the first error value if multiple are returned
+	d := e.Errors[0]
+	return fmt.Sprintf("%s: %s", d.Code, d.Message)
+}
+
+// Is reports whether the APIError matches the target error, allowing it to be
+// checked against ErrImposterNotFound using errors.Is.
+func (e *APIError) Is(target error) bool {
+	return target == ErrImposterNotFound && e.StatusCode == http.StatusNotFound
+}
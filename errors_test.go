@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ogbofjnr/mbgo"
+	"github.com/ogbofjnr/mbgo/internal/assert"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	cases := map[string]struct {
+		err    error
+		target error
+		want   bool
+	}{
+		"matches ErrImposterNotFound for a 404 status code": {
+			err: &mbgo.APIError{
+				StatusCode: http.StatusNotFound,
+				Errors: []mbgo.ErrorDetail{
+					{
+						Code:    "no such resource",
+						Message: "Try POSTing to /imposters first?",
+					},
+				},
+			},
+			target: mbgo.ErrImposterNotFound,
+			want:   true,
+		},
+		"matches ErrImposterNotFound for a wrapped 404 status code": {
+			err: fmt.Errorf("get imposter: %w", &mbgo.APIError{
+				StatusCode: http.StatusNotFound,
+			}),
+			target: mbgo.ErrImposterNotFound,
+			want:   true,
+		},
+		"does not match ErrImposterNotFound for other status codes": {
+			err: &mbgo.APIError{
+				StatusCode: http.StatusBadRequest,
+			},
+			target: mbgo.ErrImposterNotFound,
+		},
+		"does not match other errors": {
+			err: &mbgo.APIError{
+				StatusCode: http.StatusNotFound,
+			},
+			target: errors.New("imposter not found"),
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equals(t, c.want, errors.Is(c.err, c.target))
+		})
+	}
+}
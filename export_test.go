@@ -0,0 +1,11 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo
+
+import "net/http"
+
+// HTTPClient returns the inner *http.Client of the Client for testing.
+func HTTPClient(cli *Client) *http.Client {
+	return cli.restCli.HTTPClient()
+}
@@ -4,9 +4,16 @@
 package mbgo
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"reflect"
+	"sync"
+	"time"
 )
 
 // HTTPRequest describes an incoming HTTP request received by an
@@ -30,24 +37,98 @@ type HTTPRequest struct {
 	// Headers contains the HTTP headers of the request.
 	Headers http.Header
 
-	// Body is the body of the request.
+	// Body is the body of the request. In a Predicate it may be a structured
+	// value, such as a map[string]interface{}, which mountebank compares
+	// against a JSON request body field by field, regardless of key order
+	// or whitespace. A JSON object or array body is decoded as such when
+	// receiving data from mountebank, with any numbers in it decoded as
+	// json.Number values to preserve their precision, and as a string otherwise.
 	Body interface{}
 
-	// Timestamp is the timestamp of the request.
+	// Form contains the fields of an 'application/x-www-form-urlencoded' request
+	// body as parsed by mountebank. In a Predicate it matches the parsed fields
+	// regardless of their order in the body; see FormPredicate.
+	Form url.Values
+
+	// Timestamp is the timestamp of the request; see Time to parse it.
 	Timestamp string
 }
 
+// Time returns the parsed Timestamp of the HTTPRequest, such as to
+// compare the intervals between recorded requests.
+func (r HTTPRequest) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, r.Timestamp)
+}
+
+// BodyBytes returns the body of the HTTPRequest as bytes, such as to
+// decompress a recorded request body. A string body is returned as its
+// bytes and a structured body, such as a decoded JSON object, as its
+// JSON encoding. Note that mountebank records request bodies as text,
+// so bytes that are not valid UTF-8 may not be recorded exactly.
+func (r HTTPRequest) BodyBytes() ([]byte, error) {
+	switch body := r.Body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return body, nil
+	case string:
+		return []byte(body), nil
+	default:
+		return json.Marshal(body)
+	}
+}
+
+// RequestsEqual reports whether the expected and actual HTTPRequest values are
+// deeply equal, ignoring the fields named by ignore, such as the "Timestamp" and
+// "RequestFrom" fields of a recorded request that differ every time. It panics
+// if a name is not a field of HTTPRequest, such as a misspelled one.
+func RequestsEqual(expected, actual HTTPRequest, ignore ...string) bool {
+	ev := reflect.ValueOf(&expected).Elem()
+	av := reflect.ValueOf(&actual).Elem()
+	for _, name := range ignore {
+		f := ev.FieldByName(name)
+		if !f.IsValid() {
+			panic(fmt.Sprintf("mbgo: unknown HTTPRequest field: %q", name))
+		}
+		zero := reflect.Zero(f.Type())
+		f.Set(zero)
+		av.FieldByName(name).Set(zero)
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
 // TCPRequest describes incoming TCP data received by an Imposter of
 // the "tcp" protocol.
 //
 // See more information about TCP requests in mountebank at:
 // http://www.mbtest.org/docs/protocols/tcp.
 type TCPRequest struct {
-	// RequestFrom is the originating address of the incoming request.
+	// RequestFrom is the originating address of the incoming request, such as
+	// to verify which client connected; it is the IP address of the recorded
+	// 'requestFrom' socket address, without its port.
 	RequestFrom net.IP
 
-	// Data is the data in the request as plaintext.
+	// RequestFromAddr is the full recorded 'requestFrom' socket address of the
+	// incoming request including its port, such as "172.17.0.1:58112", which
+	// distinguishes the clients connecting from the same host.
+	RequestFromAddr string
+
+	// IP is the IP address of the client, as recorded by mountebank in the
+	// 'ip' field of the request.
+	IP net.IP
+
+	// Data is the data in the request as plaintext, or base64 encoded
+	// if the Imposter Mode is "binary".
 	Data string
+
+	// Timestamp is the timestamp of the request; see Time to parse it.
+	Timestamp string
+}
+
+// Time returns the parsed Timestamp of the TCPRequest, such as to
+// compare the intervals between recorded requests.
+func (r TCPRequest) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, r.Timestamp)
 }
 
 // JSONPath is a predicate parameter used to narrow the scope of a tested value
@@ -60,25 +141,185 @@ type JSONPath struct {
 	Selector string `json:"selector"`
 }
 
+// SMTPRequest describes an incoming email message received by an Imposter
+// of the "smtp" protocol.
+//
+// See more information about SMTP requests in mountebank at:
+// http://www.mbtest.org/docs/protocols/smtp.
+type SMTPRequest struct {
+	// RequestFrom is the originating address of the incoming request.
+	RequestFrom net.IP
+
+	// From is the sender of the email.
+	From EmailAddress
+
+	// To contains the recipients of the email.
+	To []EmailAddress
+
+	// Cc contains the carbon copy recipients of the email.
+	Cc []EmailAddress
+
+	// Bcc contains the blind carbon copy recipients of the email.
+	Bcc []EmailAddress
+
+	// Subject is the subject of the email.
+	Subject string
+
+	// Text is the plaintext body of the email.
+	Text string
+
+	// HTML is the HTML body of the email.
+	HTML string
+
+	// Attachments contains the attachments of the email.
+	Attachments []interface{}
+}
+
+// EmailAddress is the address and optional display name of the sender or
+// recipient of an SMTPRequest.
+type EmailAddress struct {
+	// Address is the email address.
+	Address string `json:"address"`
+
+	// Name is the display name associated with the email address.
+	Name string `json:"name,omitempty"`
+}
+
+// XPath is a predicate parameter used to narrow the scope of a tested value
+// to one found at the specified path in the request XML.
+//
+// See more information about the XPath parameter at:
+// http://www.mbtest.org/docs/api/xpath.
+type XPath struct {
+	// Selector is the XPath of the value tested against the predicate.
+	Selector string `json:"selector"`
+
+	// NS is a map of namespace prefixes to their URLs, used to resolve
+	// any namespaced elements in the Selector.
+	NS map[string]string `json:"ns,omitempty"`
+}
+
+// Predicate operators supported by mountebank.
+//
+// See more information about predicate operators at:
+// http://www.mbtest.org/docs/api/predicates.
+const (
+	// PredicateEquals matches if each request field given in the predicate
+	// equals the value of that field in the request. It is a subset match;
+	// request fields or sub-fields excluded from the predicate, such as
+	// other query parameters or headers, are not tested.
+	PredicateEquals = "equals"
+
+	// PredicateDeepEquals matches if each request field given in the
+	// predicate equals the entire value of that field in the request,
+	// including all of its sub-fields; for example, a request with query
+	// parameters not given in the predicate query does not match.
+	PredicateDeepEquals = "deepEquals"
+
+	// PredicateContains matches if the request field contains the given value.
+	PredicateContains = "contains"
+
+	// PredicateStartsWith matches if the request field starts with the given value.
+	PredicateStartsWith = "startsWith"
+
+	// PredicateEndsWith matches if the request field ends with the given value.
+	PredicateEndsWith = "endsWith"
+
+	// PredicateMatches matches if the request field matches the given regular expression.
+	PredicateMatches = "matches"
+
+	// PredicateExists matches if the request field exists, or does not exist, as given.
+	PredicateExists = "exists"
+
+	// PredicateNot matches if the given sub-predicate does not match.
+	PredicateNot = "not"
+
+	// PredicateOr matches if any of the given sub-predicates match.
+	PredicateOr = "or"
+
+	// PredicateAnd matches if all of the given sub-predicates match.
+	PredicateAnd = "and"
+
+	// PredicateInject matches if the given JavaScript predicate function returns true.
+	PredicateInject = "inject"
+)
+
+// JSFunction is the source of a JavaScript function injected into mountebank,
+// which requires mountebank to be started with --allowInjection. It is used
+// by both a Predicate and a Response of the "inject" type, and it is an error
+// to marshal an empty JSFunction.
+//
+// See more information about injection in mountebank at:
+// http://www.mbtest.org/docs/api/injection.
+type JSFunction string
+
+// InjectPredicate returns a Predicate matching a request if the given
+// JavaScript predicate function returns true.
+func InjectPredicate(fn JSFunction) Predicate {
+	return Predicate{
+		Operator: PredicateInject,
+		Request:  fn,
+	}
+}
+
 // Predicate represents conditional behaviour attached to a Stub in order
 // for it to match or not match an incoming request.
 //
 // The supported operations for a Predicate are listed at:
 // http://www.mbtest.org/docs/api/predicates.
 type Predicate struct {
-	// Operator is the conditional or logical operator of the Predicate.
+	// Operator is the conditional or logical operator of the Predicate;
+	// one of the Predicate operator constants, such as PredicateEquals.
 	Operator string
 
 	// Request is the request value challenged against the Operator;
-	// either of type HTTPRequest or TCPRequest.
+	// either of type HTTPRequest or TCPRequest. For the logical "and"
+	// and "or" operators it is a []Predicate, for "not" a Predicate, and
+	// for "inject" a JSFunction or string containing the JavaScript predicate
+	// function, which requires mountebank to be started with --allowInjection;
+	// see InjectPredicate. A decoded "inject" Request is a string. For
+	// "exists" it is a map[string]interface{} of request fields to whether
+	// they should exist, such as {"headers": {"Authorization": true}}.
 	Request interface{}
 
 	// JSONPath is the predicate parameter for narrowing the scope of JSON
 	// comparison; leave nil to disable functionality.
 	JSONPath *JSONPath
 
+	// XPath is the predicate parameter for narrowing the scope of XML
+	// comparison; leave nil to disable functionality.
+	XPath *XPath
+
 	// CaseSensitive determines if the match is case sensitive or not.
 	CaseSensitive bool
+
+	// Except is a regular expression used to strip out matching characters
+	// from the request value before it is tested against the predicate.
+	Except string
+}
+
+// CanonicalPredicate returns the Predicate p of an Imposter of the given protocol
+// as it is decoded when received from mountebank, such as to compare a submitted
+// Predicate to the one retrieved from mountebank in a test. The JSON encoding of a
+// Predicate is stable, and mountebank returns it as it was submitted, but decoding
+// it may change the Go types of its values; for example, an HTTPRequest is decoded
+// as an *HTTPRequest, and the numbers of a JSON body are decoded as json.Number
+// values. The returned Predicate is equal to the decoded one and has the same
+// JSON encoding as p; an error is returned if p cannot be marshaled.
+func CanonicalPredicate(proto string, p Predicate) (Predicate, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return Predicate{}, err
+	}
+
+	var out Predicate
+	if err = json.Unmarshal(b, &out); err != nil {
+		return Predicate{}, err
+	}
+	if err = unmarshalPredicateRecurse(proto, &out); err != nil {
+		return Predicate{}, err
+	}
+	return out, nil
 }
 
 // HTTPResponse is a Response.Value used to respond to a matched HTTPRequest.
@@ -92,14 +333,50 @@ type HTTPResponse struct {
 	// Headers are the HTTP headers in the response.
 	Headers http.Header
 
-	// Body is the body of the response. It will be JSON encoded before sending to mountebank
+	// Body is the body of the response. It will be JSON encoded before sending to mountebank.
+	// When Mode is "binary" it should be a []byte value, which is encoded as a base64 string,
+	// or a base64 encoded string; see BinaryBody to decode it.
+	// Any numbers in a JSON object or array body received from mountebank
+	// are decoded as json.Number values to preserve their precision.
 	Body interface{}
 
 	// Mode is the mode of the response; either "text" or "binary".
-	// Defaults to "text" if excluded.
+	// Defaults to "text" if excluded. Use "binary" to send bytes that are
+	// not text, such as a gzip compressed body along with the header
+	// 'Content-Encoding: gzip'.
 	Mode string
 }
 
+// Mode values of an HTTPResponse or a TCP Imposter, supported by mountebank.
+const (
+	// ModeText sends the response body or data as text.
+	ModeText = "text"
+
+	// ModeBinary sends the response body or data as the bytes of its base64
+	// encoded value. TCP Imposters also base64 encode the data of requests.
+	ModeBinary = "binary"
+)
+
+// BinaryBody returns the bytes of the body of an HTTPResponse with the
+// "binary" Mode, decoding it from base64 if it is a string value, such
+// as when the HTTPResponse is retrieved from mountebank.
+func (r HTTPResponse) BinaryBody() ([]byte, error) {
+	if r.Mode != ModeBinary {
+		return nil, fmt.Errorf("response mode is not %s: %q", ModeBinary, r.Mode)
+	}
+
+	switch body := r.Body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return body, nil
+	case string:
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, fmt.Errorf("invalid binary body type: %T", body)
+	}
+}
+
 // TCPResponse is a Response.Value to a matched incoming TCPRequest.
 //
 // See more information about TCP responses in mountebank at:
@@ -107,19 +384,259 @@ type HTTPResponse struct {
 type TCPResponse struct {
 	// Data is the data in the data contained in the response.
 	// An empty string does not respond with data, but does send
-	// the FIN bit.
+	// the FIN bit. If the Imposter Mode is "binary", Data is the
+	// base64 encoding of the bytes sent in the response.
 	Data string
 }
 
+// GRPCRequest describes an incoming gRPC call received by an Imposter of the
+// "grpc" protocol, which is implemented by a mountebank plugin rather than
+// mountebank itself. Only the fields common to gRPC plugins are modelled.
+type GRPCRequest struct {
+	// Path is the full method name of the call, such as "/example.Greeter/SayHello".
+	Path string
+
+	// Value is the request message decoded as JSON, with its numbers kept
+	// as json.Number values.
+	Value interface{}
+
+	// Metadata are the request metadata of the call by key.
+	Metadata map[string]interface{}
+}
+
+// GRPCResponse is a Response.Value to a matched incoming GRPCRequest.
+type GRPCResponse struct {
+	// Value is the response message encoded as JSON.
+	Value interface{}
+
+	// Metadata are the response metadata of the call by key.
+	Metadata map[string]interface{}
+}
+
+// Proxy is a Response.Value with the "proxy" type, which forwards incoming
+// requests to another server and records its responses. Depending on Mode,
+// the recorded responses are saved as new "is" Responses on a Stub in front
+// of the proxy, which are then returned by Imposter retrieval like any other
+// "is" Response.
+//
+// Note that mountebank does not support a timeout for proxied requests, which
+// wait on the proxied server for as long as the incoming request is kept open.
+// To fail fast against an unresponsive server, set a timeout on the client of
+// the Imposter, such as http.Client.Timeout, rather than on the Proxy.
+//
+// See more information about proxies in mountebank at:
+// http://www.mbtest.org/docs/api/proxies.
+type Proxy struct {
+	// To is the URL of the server to forward requests to, such as "https://example.com".
+	To string
+
+	// Mode is the proxy mode; one of ProxyOnce, ProxyAlways or ProxyTransparent.
+	// Defaults to ProxyOnce if excluded.
+	Mode string
+
+	// PredicateGenerators describe how to build the Predicates of the Stubs
+	// saved for recorded responses from the proxied requests. Without them,
+	// the saved Stubs match every request.
+	PredicateGenerators []PredicateGenerator
+
+	// InjectHeaders are headers added to each request forwarded to the
+	// proxied server, such as an Authorization header it requires, replacing
+	// any request headers of the same name; a Host header may be injected
+	// to reach a virtual host of the proxied server.
+	InjectHeaders map[string]string
+
+	// AddWaitBehavior adds a Wait behavior to each saved response, set to
+	// the latency observed when proxying its request, so that replaying the
+	// response simulates the latency of the proxied server.
+	AddWaitBehavior bool
+
+	// AddDecorateBehavior is a JavaScript function added as a Decorate
+	// behavior to each saved response, which post-processes the response
+	// whenever it is replayed.
+	AddDecorateBehavior string
+
+	// Cert is the PEM-formatted client certificate presented to the proxied
+	// server, such as one requiring mutual TLS authentication; used with Key.
+	Cert string
+
+	// Key is the PEM-formatted private key of the client certificate Cert.
+	Key string
+}
+
+// Mode values of a Proxy, supported by mountebank.
+//
+// See more information about proxy modes in mountebank at:
+// http://www.mbtest.org/docs/api/proxies.
+const (
+	// ProxyOnce proxies the first matching request and saves its response,
+	// which is replayed to all subsequent matching requests.
+	ProxyOnce = "proxyOnce"
+
+	// ProxyAlways proxies every request and saves each of their responses,
+	// which are only replayed once the Imposter proxies are removed.
+	ProxyAlways = "proxyAlways"
+
+	// ProxyTransparent proxies every request without saving any responses.
+	ProxyTransparent = "proxyTransparent"
+)
+
+// PredicateGenerator describes the Predicates built by a Proxy from a proxied
+// request, which are added to the Stub saved for its recorded response.
+//
+// See more information about predicate generators in mountebank at:
+// http://www.mbtest.org/docs/api/proxies#proxy-predicate-generators.
+type PredicateGenerator struct {
+	// Matches are the request fields used to build the Predicates, such as
+	// {"method": true, "path": true}. Each value is either true, or a nested
+	// map for fields with sub-fields, such as {"query": {"id": true}}.
+	Matches map[string]interface{} `json:"matches,omitempty"`
+
+	// CaseSensitive determines if the built Predicates are case sensitive or not.
+	CaseSensitive bool `json:"caseSensitive,omitempty"`
+
+	// Except is a regular expression used to strip out matching characters
+	// from the request values used by the built Predicates.
+	Except string `json:"except,omitempty"`
+
+	// JSONPath narrows the request values used by the built Predicates to
+	// the JSON value at its selector in the request body.
+	JSONPath *JSONPath `json:"jsonpath,omitempty"`
+
+	// XPath narrows the request values used by the built Predicates to
+	// the XML value at its selector in the request body.
+	XPath *XPath `json:"xpath,omitempty"`
+}
+
 // Behaviors defines the possible response behaviors for a stub.
 //
 // See more information on stub behaviours in mountebank at:
 // http://www.mbtest.org/docs/api/behaviors.
 type Behaviors struct {
-	// Wait adds latency to a response by waiting a specified number of milliseconds before sending the response.
-	Wait int `json:"wait,omitempty"`
+	// Wait adds latency to a response before sending it; either an int number of milliseconds
+	// to wait, or a string containing a JavaScript function returning the number of milliseconds,
+	// such as to add random latency, which requires mountebank to be started with --allowInjection.
+	Wait interface{}
+
+	// Decorate is a JavaScript function used to post-process the response before sending it, which
+	// requires mountebank to be started with --allowInjection; it may be combined with Wait.
+	Decorate string
+
+	// ShellTransform is a list of shell commands, each of which receives the JSON encoded request and
+	// response and outputs a transformed JSON response, applied in order before sending the response.
+	ShellTransform []string
+
+	// Copy is a list of values to copy from the request into tokens in the response.
+	Copy []CopyBehavior
+
+	// Lookup is a list of values to look up from an external data source using a key selected
+	// from the request, which then replace tokens in the response.
+	Lookup []LookupBehavior
 }
 
+// CopyBehavior describes a value selected from a request field that replaces
+// every occurrence of a token in the response.
+//
+// See more information on the copy behavior in mountebank at:
+// http://www.mbtest.org/docs/api/behaviors#behavior-copy.
+type CopyBehavior struct {
+	// From is the request field to select the value from; either a string
+	// such as "path", or a map for nested fields such as {"query": "id"}.
+	From interface{} `json:"from"`
+
+	// Into is the token in the response to replace, such as "${ID}".
+	Into string `json:"into"`
+
+	// Using is the method used to select the value from the request field.
+	Using Using `json:"using"`
+}
+
+// LookupBehavior describes a row looked up from an external data source using a
+// key selected from a request field, whose values replace every occurrence of
+// a token in the response, such as "${row}['name']".
+//
+// See more information on the lookup behavior in mountebank at:
+// http://www.mbtest.org/docs/api/behaviors#behavior-lookup.
+type LookupBehavior struct {
+	// Key describes how to select the lookup key from the request.
+	Key LookupKey `json:"key"`
+
+	// FromDataSource is the external data source containing the rows to look up.
+	FromDataSource DataSource `json:"fromDataSource"`
+
+	// Into is the token in the response to replace with the looked up row, such as "${row}".
+	Into string `json:"into"`
+}
+
+// LookupKey describes the value selected from a request field used as the key
+// of a LookupBehavior.
+type LookupKey struct {
+	// From is the request field to select the key from; either a string
+	// such as "path", or a map for nested fields such as {"query": "id"}.
+	From interface{} `json:"from"`
+
+	// Using is the method used to select the key from the request field.
+	Using Using `json:"using"`
+
+	// Index is the index of the selected value to use as the key, if the
+	// Using method selects more than one value.
+	Index int `json:"index,omitempty"`
+}
+
+// DataSource is the external data source of a LookupBehavior.
+type DataSource struct {
+	// CSV is a CSV file data source.
+	CSV *CSVDataSource `json:"csv,omitempty"`
+}
+
+// CSVDataSource is a CSV file used as the data source of a LookupBehavior.
+type CSVDataSource struct {
+	// Path is the path to the CSV file, relative to the mountebank process.
+	Path string `json:"path"`
+
+	// KeyColumn is the name of the column matched against the lookup key.
+	KeyColumn string `json:"keyColumn"`
+
+	// Delimiter is the column delimiter of the CSV file; defaults to ",".
+	Delimiter string `json:"delimiter,omitempty"`
+}
+
+// Using describes the method used by a behavior to select a value from a
+// request field.
+type Using struct {
+	// Method is the selection method; one of "regex", "xpath" or "jsonpath".
+	Method string `json:"method"`
+
+	// Selector is the regular expression, XPath or JSONPath selector of the value.
+	Selector string `json:"selector"`
+
+	// NS is a map of namespace prefixes to their URLs; only used by the "xpath" method.
+	NS map[string]string `json:"ns,omitempty"`
+
+	// Options are the regular expression options; only used by the "regex" method.
+	Options *UsingOptions `json:"options,omitempty"`
+}
+
+// UsingOptions are the regular expression options of a "regex" Using method.
+type UsingOptions struct {
+	// IgnoreCase makes the regular expression case insensitive.
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+
+	// Multiline makes the regular expression match across multiple lines.
+	Multiline bool `json:"multiline,omitempty"`
+}
+
+// Fault values of a Response with the "fault" type, supported by mountebank.
+//
+// See more information about faults in mountebank at:
+// http://www.mbtest.org/docs/api/faults.
+const (
+	// FaultConnectionResetByPeer closes the connection abruptly with a TCP RST.
+	FaultConnectionResetByPeer = "CONNECTION_RESET_BY_PEER"
+
+	// FaultRandomDataThenClose sends random data and then closes the connection.
+	FaultRandomDataThenClose = "RANDOM_DATA_THEN_CLOSE"
+)
+
 // Response defines a networked response sent by a Stub whenever an
 // incoming Request matches one of its Predicates. Each Response is
 // has a Type field that defines its behaviour. Its currently supported
@@ -127,18 +644,82 @@ type Behaviors struct {
 //	is - Merges the specified Response fields with the defaults.
 //	proxy - Proxies the request to the specified destination and returns the response.
 //	inject - Creates the Response object based on the injected Javascript.
+//	fault - Simulates a network fault, such as resetting the connection.
 //
 // See more information on stub responses in mountebank at:
 // http://www.mbtest.org/docs/api/stubs.
 type Response struct {
-	// Type is the type of the Response; one of "is", "proxy" or "inject".
+	// Type is the type of the Response; one of "is", "proxy", "inject" or "fault".
 	Type string
 
 	// Value is the value of the Response; either of type HTTPResponse or TCPResponse.
+	// For the "proxy" type it is of type Proxy, for the "inject" type it is a JSFunction
+	// or string containing the JavaScript response function, decoded as a string (see
+	// InjectResponse), and for the "fault" type it is one of the Fault constants.
 	Value interface{}
 
 	// Behaviors is an optional field allowing the user to define response behavior.
 	Behaviors *Behaviors
+
+	// Repeat is the number of times the Response is sent before moving on to
+	// the next Response of the Stub; the Response is sent once if excluded.
+	Repeat int
+}
+
+// WeightedResponse is a Response with a relative weight, used to build the
+// Responses of a Stub that are each sent in proportion to their weight.
+type WeightedResponse struct {
+	// Response is the Response to send.
+	Response Response
+
+	// Weight is the relative number of times the Response is sent.
+	Weight int
+}
+
+// WeightedResponses returns the Responses of the given WeightedResponse values
+// in order, with their Repeat set so that each is sent in proportion to its
+// weight within every cycle of the Stub responses, such as weights of 90 and 10
+// for a Response sent 9 times out of 10. The weights are reduced by their
+// greatest common divisor to keep the cycle short, and responses without a
+// positive weight are omitted.
+//
+// Note that mountebank always cycles through the Responses of a Stub in order,
+// so the responses are sent in a fixed rather than random sequence.
+func WeightedResponses(weighted ...WeightedResponse) []Response {
+	divisor := 0
+	for _, w := range weighted {
+		if w.Weight > 0 {
+			divisor = gcd(divisor, w.Weight)
+		}
+	}
+
+	var rs []Response
+	for _, w := range weighted {
+		if w.Weight <= 0 {
+			continue
+		}
+		r := w.Response
+		r.Repeat = w.Weight / divisor
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+// gcd returns the greatest common divisor of a and b.
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// InjectResponse returns a Response of the "inject" type, created by the given
+// JavaScript response function.
+func InjectResponse(fn JSFunction) Response {
+	return Response{
+		Type:  "inject",
+		Value: fn,
+	}
 }
 
 // Stub adds behaviour to Imposters where one or more registered Responses
@@ -159,6 +740,39 @@ type Stub struct {
 	// Responses are the circular queue of Responses used to respond to
 	// incoming matched requests.
 	Responses []Response
+
+	// Matches are the requests matched by the Stub along with the responses
+	// sent to them. Note that this value is only set when receiving Imposter
+	// data from a mountebank server started with the --debug flag.
+	Matches []Match
+
+	// Links are the hypermedia links of the Stub by relation, such as "self".
+	// Note that this value is only set when receiving Imposter data from the
+	// mountebank server, and is excluded from its replayable format.
+	Links map[string]Link
+}
+
+// Link is a hypermedia link to a related mountebank API resource.
+type Link struct {
+	// Href is the absolute URL of the resource.
+	Href string `json:"href"`
+}
+
+// Match describes an incoming request matched by a Stub, as well as the
+// response sent to it by mountebank.
+//
+// See more information about stub matches in mountebank at:
+// http://www.mbtest.org/docs/api/contracts?type=imposter.
+type Match struct {
+	// Timestamp is the timestamp of the match.
+	Timestamp string
+
+	// Request is the matched request; either of type HTTPRequest or TCPRequest.
+	Request interface{}
+
+	// Response is the response sent to the matched request; either of type
+	// HTTPResponse or TCPResponse.
+	Response interface{}
 }
 
 // Imposter is the primary mountebank resource, representing a server/service
@@ -171,9 +785,12 @@ type Stub struct {
 //
 // http://www.mbtest.org/docs/protocols/http
 //
+// http://www.mbtest.org/docs/protocols/https
+//
 // http://www.mbtest.org/docs/protocols/tcp
 type Imposter struct {
-	// Port is the listening port of the Imposter; required.
+	// Port is the listening port of the Imposter; a free port is assigned
+	// by mountebank during creation if excluded.
 	Port int
 
 	// Proto is the listening protocol of the Imposter; required.
@@ -184,26 +801,239 @@ type Imposter struct {
 
 	// RecordRequests adds mock verification support to the Imposter
 	// by having it remember any requests made to it, which can later
-	// be retrieved and examined by the testing environment.
+	// be retrieved and examined by the testing environment. Note that
+	// mountebank has no equivalent per-Imposter field for recording
+	// Stub matches, which are only recorded by a mountebank server
+	// started with the --debug flag; see Stub.Matches.
 	RecordRequests bool
 
 	// Requests are the list of recorded requests, or nil if RecordRequests == false.
-	// Note that the underlying type will be HTTPRequest or TCPRequest depending on
-	// the protocol of the Imposter.
+	// Note that the underlying type will be HTTPRequest, TCPRequest or SMTPRequest depending on
+	// the protocol of the Imposter, or a pointer to it when received from mountebank; see
+	// HTTPRequests and TCPRequests to retrieve them with their type. For the "grpc" protocol,
+	// they are received as *GRPCRequest values. For a custom protocol registered using
+	// RegisterProtocol, they are received as *json.RawMessage values, as are the Predicate
+	// requests and the Response values of its Stubs.
 	Requests []interface{}
 
-	// RequestCount is the number of matched requests received by the Imposter.
-	// Note that this value is only used/set when receiving Imposter data
-	// from the mountebank server.
+	// RequestCount is the number of requests received by the Imposter, as
+	// reported by mountebank in its "numberOfRequests" field. Requests are
+	// counted even if RecordRequests == false. Note that this value is only
+	// used/set when receiving Imposter data from the mountebank server.
 	RequestCount int
 
 	// AllowCORS will allow all CORS pre-flight requests on the Imposter.
+	// Only used by HTTP and HTTPS Imposters.
 	AllowCORS bool
 
-	// DefaultResponse is the default response to send if no predicate matches.
-	// Only used by HTTP and TCP Imposters; should be one of HTTPResponse or TCPResponse.
+	// DefaultResponse is the default response to send if no predicate matches,
+	// such as an HTTPResponse with a 503 status code. Only used by HTTP and TCP
+	// Imposters; should be one of HTTPResponse or TCPResponse, and is decoded
+	// as a *HTTPResponse or *TCPResponse when receiving Imposter data from the
+	// mountebank server.
 	DefaultResponse interface{}
 
 	// Stubs contains zero or more valid Stubs associated with the Imposter.
 	Stubs []Stub
+
+	// Key is the PEM-formatted SSL private key of the Imposter. Only used by
+	// HTTPS Imposters; defaults to a self-signed key if excluded.
+	Key string
+
+	// Cert is the PEM-formatted SSL certificate of the Imposter. Only used by
+	// HTTPS Imposters; defaults to a self-signed certificate if excluded.
+	Cert string
+
+	// MutualAuth will make the Imposter request a client certificate.
+	// Only used by HTTPS Imposters.
+	MutualAuth bool
+
+	// Ciphers is the list of supported SSL ciphers of the Imposter, in the
+	// OpenSSL cipher list format. Only used by HTTPS Imposters.
+	Ciphers string
+
+	// Mode is the mode of the request and response data; either "text" or
+	// "binary". Only used by TCP Imposters; defaults to "text" if excluded.
+	Mode string
+
+	// EndOfRequestResolver determines when an incoming request is complete,
+	// such as for framed or length-prefixed protocols. Only used by TCP
+	// Imposters; each packet received is treated as a request if excluded.
+	EndOfRequestResolver *Resolver
+
+	// Links are the hypermedia links of the Imposter by relation, such as
+	// "self" and "stubs". Note that this value is only set when receiving
+	// Imposter data from the mountebank server, and is excluded from its
+	// replayable format.
+	Links map[string]Link
+
+	// Protofile is the path of the .proto file defining the services of the
+	// Imposter. Only used by Imposters of a custom protocol, such as "grpc".
+	Protofile string
+
+	// Services are the gRPC services implemented by the Imposter by their full
+	// name, such as "example.Greeter". Only used by Imposters of a custom
+	// protocol, such as "grpc".
+	Services map[string]GRPCService
+
+	// Options are any other fields of an Imposter of a custom protocol,
+	// implemented by a mountebank plugin such as one for gRPC, which are
+	// sent and received alongside the fields above. They are not used by
+	// the protocols built into mountebank, and are only received for the
+	// "grpc" protocol and the custom protocols registered using
+	// RegisterProtocol. Fields of the same name as the fields above are
+	// never included.
+	Options map[string]interface{}
+}
+
+// GRPCService describes a gRPC service implemented by an Imposter.
+type GRPCService struct {
+	// File is the path of the .proto file defining the service.
+	File string `json:"file"`
+}
+
+// Resolver is the EndOfRequestResolver of a TCP Imposter.
+//
+// See more information about resolving the end of TCP requests in mountebank at:
+// http://www.mbtest.org/docs/protocols/tcp.
+type Resolver struct {
+	// Inject is a JavaScript function that receives the request data
+	// received so far and returns true once the request is complete,
+	// which requires mountebank to be started with --allowInjection.
+	Inject string `json:"inject"`
+}
+
+// protoGRPC is the name of the custom protocol of gRPC plugins.
+const protoGRPC = "grpc"
+
+// customProtocols is the set of custom protocols registered using
+// RegisterProtocol.
+var customProtocols sync.Map
+
+// RegisterProtocol registers the name of a custom protocol implemented by
+// a mountebank plugin, so that Imposters of the protocol received from
+// mountebank are decoded along with their Options, and with the requests
+// and responses of their Stubs as *json.RawMessage values. Decoding the
+// requests or responses of an Imposter of any other unknown protocol, such
+// as a misspelled one, returns an error instead. The names of the protocols
+// built into mountebank and of the "grpc" protocol, which is decoded into
+// GRPCRequest and GRPCResponse values, are ignored. It is safe to call
+// RegisterProtocol concurrently, such as from tests run in parallel.
+func RegisterProtocol(proto string) {
+	if !isBuiltinProtocol(proto) && proto != protoGRPC {
+		customProtocols.Store(proto, struct{}{})
+	}
+}
+
+// Validate checks that the Imposter has the fields required to create it,
+// returning an error describing the first invalid field found. Fields that
+// are only used by other protocols than the Imposter's, which would not be
+// sent to mountebank, are also invalid. Fields that depend on the mountebank
+// server, such as whether the port is free or the protocol is supported, are
+// left to mountebank to validate.
+func (imp Imposter) Validate() error {
+	if imp.Proto == "" {
+		return errors.New("invalid imposter: Proto is required")
+	}
+
+	isHTTP := imp.Proto == "http" || imp.Proto == "https"
+	if imp.AllowCORS && !isHTTP {
+		return fmt.Errorf("invalid imposter: AllowCORS is only used by the http and https protocols, not %q", imp.Proto)
+	}
+
+	if imp.Proto == "https" {
+		if (imp.Key == "") != (imp.Cert == "") {
+			return errors.New("invalid imposter: Key and Cert must be set together for the https protocol")
+		}
+	} else {
+		switch {
+		case imp.Key != "":
+			return fmt.Errorf("invalid imposter: Key is only used by the https protocol, not %q", imp.Proto)
+		case imp.Cert != "":
+			return fmt.Errorf("invalid imposter: Cert is only used by the https protocol, not %q", imp.Proto)
+		case imp.MutualAuth:
+			return fmt.Errorf("invalid imposter: MutualAuth is only used by the https protocol, not %q", imp.Proto)
+		case imp.Ciphers != "":
+			return fmt.Errorf("invalid imposter: Ciphers is only used by the https protocol, not %q", imp.Proto)
+		}
+	}
+
+	if isBuiltinProtocol(imp.Proto) {
+		switch {
+		case imp.Protofile != "":
+			return fmt.Errorf("invalid imposter: Protofile is only used by custom protocols, not %q", imp.Proto)
+		case imp.Services != nil:
+			return fmt.Errorf("invalid imposter: Services is only used by custom protocols, not %q", imp.Proto)
+		}
+	}
+
+	if imp.Proto == "tcp" {
+		switch imp.Mode {
+		case "", ModeText, ModeBinary:
+		default:
+			return fmt.Errorf("invalid imposter: Mode must be %q or %q for the tcp protocol: %q", ModeText, ModeBinary, imp.Mode)
+		}
+		if imp.EndOfRequestResolver != nil && imp.EndOfRequestResolver.Inject == "" {
+			return errors.New("invalid imposter: EndOfRequestResolver.Inject is required")
+		}
+	} else {
+		switch {
+		case imp.Mode != "":
+			return fmt.Errorf("invalid imposter: Mode is only used by the tcp protocol, not %q", imp.Proto)
+		case imp.EndOfRequestResolver != nil:
+			return fmt.Errorf("invalid imposter: EndOfRequestResolver is only used by the tcp protocol, not %q", imp.Proto)
+		}
+	}
+
+	return nil
+}
+
+// HTTPRequests returns the recorded Requests of an Imposter of the "http" or
+// "https" protocol as HTTPRequest values, returning an error if the Imposter
+// is of another protocol or a request is not an HTTPRequest.
+func (imp Imposter) HTTPRequests() ([]HTTPRequest, error) {
+	if imp.Proto != "http" && imp.Proto != "https" {
+		return nil, fmt.Errorf("imposter protocol is not http or https: %q", imp.Proto)
+	}
+	if imp.Requests == nil {
+		return nil, nil
+	}
+
+	rs := make([]HTTPRequest, len(imp.Requests))
+	for i, r := range imp.Requests {
+		switch r := r.(type) {
+		case HTTPRequest:
+			rs[i] = r
+		case *HTTPRequest:
+			rs[i] = *r
+		default:
+			return nil, fmt.Errorf("invalid type of request %d: %T", i, r)
+		}
+	}
+	return rs, nil
+}
+
+// TCPRequests returns the recorded Requests of an Imposter of the "tcp"
+// protocol as TCPRequest values, returning an error if the Imposter is
+// of another protocol or a request is not a TCPRequest.
+func (imp Imposter) TCPRequests() ([]TCPRequest, error) {
+	if imp.Proto != "tcp" {
+		return nil, fmt.Errorf("imposter protocol is not tcp: %q", imp.Proto)
+	}
+	if imp.Requests == nil {
+		return nil, nil
+	}
+
+	rs := make([]TCPRequest, len(imp.Requests))
+	for i, r := range imp.Requests {
+		switch r := r.(type) {
+		case TCPRequest:
+			rs[i] = r
+		case *TCPRequest:
+			rs[i] = *r
+		default:
+			return nil, fmt.Errorf("invalid type of request %d: %T", i, r)
+		}
+	}
+	return rs, nil
 }
@@ -36,3 +36,15 @@ func MustOk(tb testing.TB, err error) {
 		tb.Fatalf("fatal error: %#v\n\n", err)
 	}
 }
+
+// EqualError fails the test if the actual error is nil or its message
+// does not match the message of the expected error.
+func EqualError(tb testing.TB, expected, actual error) {
+	tb.Helper()
+
+	if actual == nil {
+		tb.Errorf("\n\n\texpected error: %q\n\n\tactual: nil\n\n", expected.Error())
+	} else if expected.Error() != actual.Error() {
+		tb.Errorf("\n\n\texpected error: %q\n\n\tactual: %q\n\n", expected.Error(), actual.Error())
+	}
+}
@@ -7,9 +7,12 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // Client represents a generic HTTP REST client that handles
@@ -17,21 +20,97 @@ import (
 type Client struct {
 	baseURL    *url.URL
 	httpClient *http.Client
+	header     http.Header
+	headerFns  []func(context.Context) http.Header
+	observers  []ObserveFunc
+	strict     bool
+	maxBody    int64
 }
 
+// ObserveFunc is called by Do after sending each request with its method,
+// URL path, response status code, or zero if no response is received, and
+// the duration until the response headers were received.
+type ObserveFunc func(method, path string, status int, dur time.Duration)
+
+// DefaultMaxBodySize is the default maximum size in bytes of a response
+// body decoded by DecodeResponseBody.
+const DefaultMaxBodySize = 64 << 20
+
 // NewClient returns a new instance of *Client from the provided
 // inner *http.Client httpClient and API base *url.URL baseURL.
 func NewClient(cli *http.Client, root *url.URL) *Client {
 	return &Client{
 		httpClient: cli,
 		baseURL:    root,
+		maxBody:    DefaultMaxBodySize,
+	}
+}
+
+// HTTPClient returns the inner *http.Client used to send requests.
+func (cli *Client) HTTPClient() *http.Client {
+	return cli.httpClient
+}
+
+// WrapTransport replaces the transport of the inner *http.Client with the
+// http.RoundTripper returned by fn, given the current transport. The inner
+// *http.Client is copied beforehand so that the original is not modified.
+func (cli *Client) WrapTransport(fn func(http.RoundTripper) http.RoundTripper) {
+	hc := *cli.httpClient
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
 	}
+	hc.Transport = fn(rt)
+	cli.httpClient = &hc
+}
+
+// AddHeaders adds the values of the provided http.Header h to the headers
+// of every request built by NewRequest, replacing any existing values of
+// the same keys, including the default 'Accept' and 'Content-Type' headers.
+// Keys without any values remove the header from the request instead.
+func (cli *Client) AddHeaders(h http.Header) {
+	if cli.header == nil {
+		cli.header = make(http.Header, len(h))
+	}
+	for k, vs := range h {
+		cli.header[http.CanonicalHeaderKey(k)] = append([]string(nil), vs...)
+	}
+}
+
+// AddHeaderFunc adds a function called by NewRequest with the request
+// context, whose returned headers are applied to the request as with
+// AddHeaders, after any headers added by AddHeaders and any previously
+// added functions.
+func (cli *Client) AddHeaderFunc(fn func(context.Context) http.Header) {
+	cli.headerFns = append(cli.headerFns, fn)
+}
+
+// AddObserver adds a function called by Do after sending each request.
+func (cli *Client) AddObserver(fn ObserveFunc) {
+	cli.observers = append(cli.observers, fn)
+}
+
+// DisallowUnknownFields makes DecodeResponseBody return an error when
+// decoding a JSON object with a field that does not match any field of
+// the destination value, rather than silently ignoring the field.
+func (cli *Client) DisallowUnknownFields() {
+	cli.strict = true
+}
+
+// SetMaxBodySize sets the maximum size in bytes of a response body decoded
+// by DecodeResponseBody, which returns an error once more than n bytes
+// are read. A non-positive n removes the limit.
+func (cli *Client) SetMaxBodySize(n int64) {
+	cli.maxBody = n
 }
 
 // NewRequest builds the specified *http.Request value from the
 // provided request method, path, body and optional body/query
 // parameters, with the appropriate headers set depending on
-// the particular request method.
+// the particular request method. The query parameters are
+// encoded deterministically, sorted by key with the values of
+// each key kept in their given order, so that equal parameters
+// always produce the same raw query.
 func (cli *Client) NewRequest(ctx context.Context, method, path string, body io.Reader, q url.Values) (*http.Request, error) {
 	u := cli.baseURL.ResolveReference(&url.URL{Path: path})
 	u.RawQuery = q.Encode()
@@ -46,20 +125,143 @@ func (cli *Client) NewRequest(ctx context.Context, method, path string, body io.
 	case http.MethodPost, http.MethodPut:
 		req.Header.Set("Content-Type", "application/json")
 	}
+	setHeaders(req.Header, cli.header)
+	for _, fn := range cli.headerFns {
+		setHeaders(req.Header, fn(ctx))
+	}
 
 	return req.WithContext(ctx), nil
 }
 
-// Do sends an HTTP request and returns an HTTP response.
+// setHeaders replaces the values of dst with the values of the same keys
+// in src, removing the keys of src without any values from dst instead.
+func setHeaders(dst, src http.Header) {
+	for k, vs := range src {
+		k = http.CanonicalHeaderKey(k)
+		if len(vs) == 0 {
+			dst.Del(k)
+			continue
+		}
+		dst[k] = append([]string(nil), vs...)
+	}
+}
+
+// Do sends an HTTP request and returns an HTTP response. Reading the
+// response body fails once the request context is done, so that reading
+// large response bodies also honours the request context.
 func (cli *Client) Do(req *http.Request) (*http.Response, error) {
-	return cli.httpClient.Do(req)
+	start := time.Now()
+	resp, err := cli.httpClient.Do(req)
+	if len(cli.observers) > 0 {
+		dur, status := time.Since(start), 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		for _, fn := range cli.observers {
+			fn(req.Method, req.URL.Path, status, dur)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &contextReader{ctx: req.Context(), rc: resp.Body}
+	return resp, nil
+}
+
+// contextReader is an io.ReadCloser that fails to read once its context is done.
+type contextReader struct {
+	ctx context.Context
+	rc  io.ReadCloser
+}
+
+// Read satisfies the io.Reader interface.
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.rc.Read(p)
+}
+
+// Close satisfies the io.Closer interface.
+func (r *contextReader) Close() error {
+	return r.rc.Close()
+}
+
+// limitReader is an io.Reader that fails to read once more than max bytes
+// have been read from the underlying io.Reader.
+type limitReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+// newLimitReader returns a new *limitReader reading from r.
+func newLimitReader(r io.Reader, max int64) *limitReader {
+	return &limitReader{
+		r:   io.LimitReader(r, max+1),
+		max: max,
+	}
+}
+
+// Read satisfies the io.Reader interface.
+func (r *limitReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.read > r.max {
+		return n - int(r.read-r.max), fmt.Errorf("response body exceeds the maximum size of %d bytes", r.max)
+	}
+	return n, err
+}
+
+// maxDrainSize is the maximum number of unread bytes of a response body
+// read by CloseBody before closing it.
+const maxDrainSize = 64 << 10
+
+// CloseBody reads and discards the rest of the provided HTTP response body,
+// up to a limit, and then closes it, so that its connection can be reused
+// by the *http.Client for the next request.
+func CloseBody(body io.ReadCloser) error {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, maxDrainSize))
+	return body.Close()
 }
 
 // DecodeResponseBody reads a JSON-encoded value from the provided
 // HTTP response body and stores it into the value pointed to by v
-// and closes the body after reading.
+// and closes the body after reading using CloseBody. An error is returned if the
+// body is larger than the maximum size set by SetMaxBodySize. If v
+// is nil or the body is empty, nil is returned and v is unchanged.
 func (cli *Client) DecodeResponseBody(body io.ReadCloser, v interface{}) error {
-	defer body.Close()
+	return cli.decodeBody(body, v, cli.strict)
+}
+
+// DecodeErrorBody is like DecodeResponseBody, but always ignores unknown
+// fields in the JSON, even if DisallowUnknownFields has been called. It is
+// used to decode error responses, whose objects may have fields other than
+// those decoded into v depending on the error.
+func (cli *Client) DecodeErrorBody(body io.ReadCloser, v interface{}) error {
+	return cli.decodeBody(body, v, false)
+}
+
+// decodeBody decodes the JSON-encoded body into v as described by
+// DecodeResponseBody, disallowing unknown fields if strict is true.
+func (cli *Client) decodeBody(body io.ReadCloser, v interface{}, strict bool) error {
+	defer CloseBody(body)
 
-	return json.NewDecoder(body).Decode(v)
+	if v == nil {
+		return nil
+	}
+
+	var r io.Reader = body
+	if cli.maxBody > 0 {
+		r = newLimitReader(body, cli.maxBody)
+	}
+
+	dec := json.NewDecoder(r)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != io.EOF {
+		return err
+	}
+	return nil
 }
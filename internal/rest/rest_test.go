@@ -26,11 +26,13 @@ func TestClient_NewRequest(t *testing.T) {
 		Description string
 
 		// inputs
-		Root   *url.URL
-		Method string
-		Path   string
-		Body   io.Reader
-		Query  url.Values
+		Root       *url.URL
+		Method     string
+		Path       string
+		Body       io.Reader
+		Query      url.Values
+		Header     http.Header
+		HeaderFunc func(context.Context) http.Header
 
 		// output expectations
 		AssertFunc func(*testing.T, *http.Request, error)
@@ -149,6 +151,79 @@ func TestClient_NewRequest(t *testing.T) {
 				assert.Equals(t, expected.WithContext(context.Background()), actual)
 			},
 		},
+		{
+			Description: "should add the client headers to the request headers, replacing any defaults",
+			Root:        &url.URL{},
+			Method:      http.MethodPost,
+			Header: http.Header{
+				"authorization": []string{"Bearer abc123"},
+				"Content-Type":  []string{"application/json; charset=utf-8"},
+			},
+			AssertFunc: func(t *testing.T, actual *http.Request, err error) {
+				assert.Ok(t, err)
+				expected := &http.Request{
+					Method:     http.MethodPost,
+					URL:        &url.URL{},
+					Proto:      "HTTP/1.1",
+					ProtoMajor: 1,
+					ProtoMinor: 1,
+					Header: http.Header{
+						"Accept":        []string{"application/json"},
+						"Authorization": []string{"Bearer abc123"},
+						"Content-Type":  []string{"application/json; charset=utf-8"},
+					},
+				}
+				assert.Equals(t, expected.WithContext(context.Background()), actual)
+			},
+		},
+		{
+			Description: "should encode the query parameters sorted by key, keeping the order of values",
+			Root:        &url.URL{},
+			Method:      http.MethodGet,
+			Query: url.Values{
+				"start":      []string{"2", "1"},
+				"replayable": []string{"true"},
+				"end":        []string{"5"},
+			},
+			AssertFunc: func(t *testing.T, actual *http.Request, err error) {
+				assert.Ok(t, err)
+				assert.Equals(t, "end=5&replayable=true&start=2&start=1", actual.URL.RawQuery)
+			},
+		},
+		{
+			Description: "should remove the request headers of client header keys without values",
+			Root:        &url.URL{},
+			Method:      http.MethodGet,
+			Header: http.Header{
+				"Accept": []string{},
+			},
+			AssertFunc: func(t *testing.T, actual *http.Request, err error) {
+				assert.Ok(t, err)
+				assert.Equals(t, http.Header{}, actual.Header)
+			},
+		},
+		{
+			Description: "should apply the headers returned by the client header function after the client headers",
+			Root:        &url.URL{},
+			Method:      http.MethodGet,
+			Header: http.Header{
+				"X-Request-Id": []string{"static"},
+				"X-Api-Key":    []string{"abc123"},
+			},
+			HeaderFunc: func(ctx context.Context) http.Header {
+				return http.Header{
+					"x-request-id": []string{"dynamic"},
+					"Accept":       nil,
+				}
+			},
+			AssertFunc: func(t *testing.T, actual *http.Request, err error) {
+				assert.Ok(t, err)
+				assert.Equals(t, http.Header{
+					"X-Request-Id": []string{"dynamic"},
+					"X-Api-Key":    []string{"abc123"},
+				}, actual.Header)
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -158,6 +233,12 @@ func TestClient_NewRequest(t *testing.T) {
 			t.Parallel()
 
 			cli := rest.NewClient(nil, c.Root)
+			if c.Header != nil {
+				cli.AddHeaders(c.Header)
+			}
+			if c.HeaderFunc != nil {
+				cli.AddHeaderFunc(c.HeaderFunc)
+			}
 			req, err := cli.NewRequest(context.Background(), c.Method, c.Path, c.Body, c.Query)
 			c.AssertFunc(t, req, err)
 		})
@@ -175,8 +256,10 @@ func TestClient_DecodeResponseBody(t *testing.T) {
 		Description string
 
 		// inputs
-		Body  io.ReadCloser
-		Value interface{}
+		Body    io.ReadCloser
+		Value   interface{}
+		Strict  bool
+		MaxBody int64
 
 		// output expectations
 		Expected interface{}
@@ -202,6 +285,77 @@ func TestClient_DecodeResponseBody(t *testing.T) {
 				Foo:  "bar",
 			},
 		},
+		{
+			Description: "should ignore unknown fields in the JSON by default",
+			Body:        ioutil.NopCloser(strings.NewReader(`{"test":true,"foo":"bar","_links":{}}`)),
+			Value:       &testDTO{},
+			Expected: &testDTO{
+				Test: true,
+				Foo:  "bar",
+			},
+		},
+		{
+			Description: "should return an error for unknown fields in the JSON if strict",
+			Body:        ioutil.NopCloser(strings.NewReader(`{"test":true,"foo":"bar","_links":{}}`)),
+			Value:       &testDTO{},
+			Strict:      true,
+			Expected: &testDTO{
+				Test: true,
+				Foo:  "bar",
+			},
+			Err: errors.New(`json: unknown field "_links"`),
+		},
+		{
+			Description: "should return nil and leave the value unchanged if the body is empty",
+			Body:        ioutil.NopCloser(strings.NewReader("")),
+			Value:       &testDTO{Foo: "bar"},
+			Expected:    &testDTO{Foo: "bar"},
+		},
+		{
+			Description: "should return nil if the body only contains whitespace",
+			Body:        ioutil.NopCloser(strings.NewReader(" \n")),
+			Value:       &testDTO{},
+			Expected:    &testDTO{},
+		},
+		{
+			Description: "should return nil without decoding if the value is nil",
+			Body:        ioutil.NopCloser(strings.NewReader(`{"test":true}`)),
+		},
+		{
+			Description: "should return an error if the body is truncated",
+			Body:        ioutil.NopCloser(strings.NewReader(`{"test":tr`)),
+			Value:       &testDTO{},
+			Expected:    &testDTO{},
+			Err:         io.ErrUnexpectedEOF,
+		},
+		{
+			Description: "should return an error if the body exceeds the maximum size",
+			Body:        ioutil.NopCloser(strings.NewReader(`{"test":true,"foo":"bar"}`)),
+			Value:       &testDTO{},
+			MaxBody:     10,
+			Expected:    &testDTO{},
+			Err:         errors.New("response body exceeds the maximum size of 10 bytes"),
+		},
+		{
+			Description: "should unmarshal the expected JSON if the body is within the maximum size",
+			Body:        ioutil.NopCloser(strings.NewReader(`{"test":true,"foo":"bar"}`)),
+			Value:       &testDTO{},
+			MaxBody:     25,
+			Expected: &testDTO{
+				Test: true,
+				Foo:  "bar",
+			},
+		},
+		{
+			Description: "should not limit the body size if the maximum size is not positive",
+			Body:        ioutil.NopCloser(strings.NewReader(`{"test":true,"foo":"bar"}`)),
+			Value:       &testDTO{},
+			MaxBody:     -1,
+			Expected: &testDTO{
+				Test: true,
+				Foo:  "bar",
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -211,6 +365,12 @@ func TestClient_DecodeResponseBody(t *testing.T) {
 			t.Parallel()
 
 			cli := rest.NewClient(nil, nil)
+			if c.Strict {
+				cli.DisallowUnknownFields()
+			}
+			if c.MaxBody != 0 {
+				cli.SetMaxBodySize(c.MaxBody)
+			}
 			err := cli.DecodeResponseBody(c.Body, c.Value)
 			if c.Err != nil {
 				assert.Equals(t, c.Err, err)
@@ -221,3 +381,66 @@ func TestClient_DecodeResponseBody(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_DecodeErrorBody(t *testing.T) {
+	t.Parallel()
+
+	cli := rest.NewClient(nil, nil)
+	cli.DisallowUnknownFields()
+
+	var v testDTO
+	body := ioutil.NopCloser(strings.NewReader(`{"test":true,"foo":"bar","data":"baz"}`))
+	err := cli.DecodeErrorBody(body, &v)
+	assert.Ok(t, err)
+	assert.Equals(t, testDTO{Test: true, Foo: "bar"}, v)
+}
+
+func TestClient_Do(t *testing.T) {
+	t.Run("should fail to read the response body once the request context is done", func(t *testing.T) {
+		t.Parallel()
+
+		cli := rest.NewClient(&http.Client{
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"test":true,"foo":"bar"}`)),
+				}, nil
+			}),
+		}, &url.URL{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := cli.NewRequest(ctx, http.MethodGet, "/foo", nil, nil)
+		assert.MustOk(t, err)
+
+		resp, err := cli.Do(req)
+		assert.MustOk(t, err)
+
+		cancel()
+		err = cli.DecodeResponseBody(resp.Body, &testDTO{})
+		assert.Equals(t, context.Canceled, err)
+	})
+}
+
+// closeRecorder is an io.ReadCloser recording whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+// Close satisfies the io.Closer interface.
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestCloseBody(t *testing.T) {
+	t.Parallel()
+
+	data := strings.NewReader(`{"test":true}` + "\n")
+	body := &closeRecorder{Reader: data}
+
+	err := rest.CloseBody(body)
+	assert.Ok(t, err)
+	assert.Equals(t, 0, data.Len())
+	assert.Equals(t, true, body.closed)
+}
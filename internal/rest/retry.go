@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryTransport is an http.RoundTripper that retries requests which fail
+// to connect to the server, such as when it is not yet listening, with an
+// exponential backoff between each attempt. Requests that receive any
+// response from the server, including error responses, are never retried.
+type RetryTransport struct {
+	next    http.RoundTripper
+	max     int
+	backoff time.Duration
+}
+
+// NewRetryTransport returns a new instance of *RetryTransport wrapping the
+// next http.RoundTripper, which retries a request at most max times, waiting
+// for backoff before the first retry and doubling the wait after each one.
+func NewRetryTransport(next http.RoundTripper, max int, backoff time.Duration) *RetryTransport {
+	return &RetryTransport{
+		next:    next,
+		max:     max,
+		backoff: backoff,
+	}
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	wait := t.backoff
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			// The request body may have been closed by the failed attempt,
+			// so send a copy of the request with a fresh body instead.
+			r = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.next.RoundTrip(r)
+		if err == nil || attempt >= t.max || !isDialError(err) || !canRetry(req) {
+			return resp, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// isDialError determines if the error occurred while connecting to the server,
+// in which case the request was never sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// canRetry determines if the request can be sent again, which requires
+// that its body, if any, can be read again.
+func canRetry(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ogbofjnr/mbgo/internal/assert"
+	"github.com/ogbofjnr/mbgo/internal/rest"
+)
+
+// roundTripperFunc is an adapter allowing a function to be used as an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// failingTransport returns an http.RoundTripper that fails with err for the
+// first n requests, and responds with http.StatusNoContent afterwards. The
+// body of each request is appended to bodies, if not nil.
+func failingTransport(n int, err error, attempts *int, bodies *[]string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		*attempts++
+		if bodies != nil && req.Body != nil {
+			b, _ := ioutil.ReadAll(req.Body)
+			*bodies = append(*bodies, string(b))
+		}
+		if *attempts <= n {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+	})
+}
+
+var errDial = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func TestRetryTransport_RoundTrip(t *testing.T) {
+	cases := []struct {
+		// general
+		Description string
+
+		// inputs
+		Failures int
+		Err      error
+		Max      int
+
+		// output expectations
+		Attempts int
+		Status   int
+		ExpErr   bool
+	}{
+		{
+			Description: "should not retry a successful request",
+			Max:         3,
+			Attempts:    1,
+			Status:      http.StatusNoContent,
+		},
+		{
+			Description: "should retry a request failing to connect until it succeeds",
+			Failures:    2,
+			Err:         errDial,
+			Max:         3,
+			Attempts:    3,
+			Status:      http.StatusNoContent,
+		},
+		{
+			Description: "should stop retrying a request failing to connect after the maximum retries",
+			Failures:    5,
+			Err:         errDial,
+			Max:         2,
+			Attempts:    3,
+			ExpErr:      true,
+		},
+		{
+			Description: "should not retry a request failing for reasons other than connecting",
+			Failures:    1,
+			Err:         &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")},
+			Max:         3,
+			Attempts:    1,
+			ExpErr:      true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.Description, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts int
+			rt := rest.NewRetryTransport(failingTransport(c.Failures, c.Err, &attempts, nil), c.Max, time.Millisecond)
+
+			req, err := http.NewRequest(http.MethodGet, "http://localhost:2525/imposters", nil)
+			assert.MustOk(t, err)
+
+			resp, err := rt.RoundTrip(req)
+			if c.ExpErr {
+				assert.Equals(t, true, err != nil)
+			} else {
+				assert.Ok(t, err)
+				assert.Equals(t, c.Status, resp.StatusCode)
+			}
+			assert.Equals(t, c.Attempts, attempts)
+		})
+	}
+
+	t.Run("should resend the request body on each retry", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int
+		var bodies []string
+		rt := rest.NewRetryTransport(failingTransport(1, errDial, &attempts, &bodies), 1, time.Millisecond)
+
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:2525/imposters", strings.NewReader(`{"port":8080}`))
+		assert.MustOk(t, err)
+
+		_, err = rt.RoundTrip(req)
+		assert.Ok(t, err)
+		assert.Equals(t, []string{`{"port":8080}`, `{"port":8080}`}, bodies)
+	})
+
+	t.Run("should stop retrying when the request context is done", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts int
+		rt := rest.NewRetryTransport(failingTransport(5, errDial, &attempts, nil), 5, time.Hour)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		req, err := http.NewRequest(http.MethodGet, "http://localhost:2525/imposters", nil)
+		assert.MustOk(t, err)
+
+		_, err = rt.RoundTrip(req.WithContext(ctx))
+		assert.Equals(t, context.DeadlineExceeded, err)
+		assert.Equals(t, 1, attempts)
+	})
+}
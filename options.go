@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/ogbofjnr/mbgo/internal/rest"
+)
+
+// Option is a functional option used to configure the optional behaviour
+// of a Client created by NewClient.
+type Option func(*Client)
+
+// WithRetry returns an Option that retries any request failing to connect
+// to the mountebank server, such as when it has not started listening yet,
+// at most max times. The client waits for backoff before the first retry,
+// doubling the wait after each one, and stops immediately if the request
+// context is done. Requests that receive a response, such as a validation
+// error, are never retried.
+func WithRetry(max int, backoff time.Duration) Option {
+	return func(cli *Client) {
+		cli.restCli.WrapTransport(func(rt http.RoundTripper) http.RoundTripper {
+			return rest.NewRetryTransport(rt, max, backoff)
+		})
+	}
+}
+
+// WithRoundTripper returns an Option that wraps the transport of the underlying
+// *http.Client with the http.RoundTripper returned by fn, given the current one,
+// such as to log requests or record metrics. Each wrapping option wraps the
+// transport set by the options before it, including WithRetry, so the last one
+// sees each request first. The provided *http.Client itself is not modified.
+func WithRoundTripper(fn func(http.RoundTripper) http.RoundTripper) Option {
+	return func(cli *Client) {
+		cli.restCli.WrapTransport(fn)
+	}
+}
+
+// WithHeaders returns an Option that adds the values of the provided
+// http.Header h to every request sent to the mountebank server, such
+// as an API key required by a gateway in front of it. Values replace
+// any existing values of the same keys, including the default 'Accept'
+// and 'Content-Type' headers, and keys without any values remove the header.
+func WithHeaders(h http.Header) Option {
+	return func(cli *Client) {
+		cli.restCli.AddHeaders(h)
+	}
+}
+
+// WithHeaderFunc returns an Option that calls fn with the context of every
+// request sent to the mountebank server and adds the returned headers to the
+// request, such as a request ID used to correlate traces. The headers are
+// applied as with WithHeaders, after the headers of any other options.
+func WithHeaderFunc(fn func(context.Context) http.Header) Option {
+	return func(cli *Client) {
+		cli.restCli.AddHeaderFunc(fn)
+	}
+}
+
+// WithAccept returns an Option that replaces the default 'Accept: application/json'
+// header of every request sent to the mountebank server with the provided value,
+// or omits the header entirely if it is empty.
+func WithAccept(accept string) Option {
+	vs := []string{}
+	if accept != "" {
+		vs = append(vs, accept)
+	}
+	return WithHeaders(http.Header{
+		"Accept": vs,
+	})
+}
+
+// WithUserAgent returns an Option that replaces the default 'User-Agent: mbgo/<version>'
+// header of every request sent to the mountebank server with the provided value, such
+// as to tell requests of different test suites apart in the mountebank logs. If it is
+// empty, the default 'User-Agent' header of the underlying *http.Client is sent instead.
+func WithUserAgent(userAgent string) Option {
+	vs := []string{}
+	if userAgent != "" {
+		vs = append(vs, userAgent)
+	}
+	return WithHeaders(http.Header{
+		"User-Agent": vs,
+	})
+}
+
+// WithBasicAuth returns an Option that authenticates every request sent to
+// the mountebank server using HTTP basic authentication with the provided
+// username and password, such as when it is hosted behind an authenticating
+// proxy.
+func WithBasicAuth(username, password string) Option {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return WithHeaders(http.Header{
+		"Authorization": []string{"Basic " + auth},
+	})
+}
+
+// WithStrictDecoding returns an Option that makes the Client return an error
+// when a response from the mountebank server contains a JSON field unknown to
+// the value it is decoded into, such as after a change to the server schema.
+// Note that it only applies to the fields of values decoded using the standard
+// encoding/json rules, which are the top-level and Process fields of Config and
+// the fields of Log. It does not apply to Imposter values and their contents,
+// which model only part of the mountebank data and always ignore the rest, nor
+// to the Config.Options, which are kept in ConfigOptions.Other, error responses,
+// which are always decoded into an *APIError, or the requests streamed by
+// Client.StreamRequests.
+func WithStrictDecoding() Option {
+	return func(cli *Client) {
+		cli.restCli.DisallowUnknownFields()
+	}
+}
+
+// WithMaxResponseSize returns an Option that limits the size of a response
+// body decoded from the mountebank server to at most n bytes, returning an
+// error for larger responses rather than reading them into memory. The limit
+// defaults to 64 MiB, and a non-positive n removes it. Note that it does not
+// apply to Client.StreamRequests, which never holds the full response in memory.
+func WithMaxResponseSize(n int64) Option {
+	return func(cli *Client) {
+		cli.restCli.SetMaxBodySize(n)
+	}
+}
+
+// Observer observes the requests sent by a Client to the mountebank server,
+// such as to record metrics of their count and latency; see WithObserver.
+type Observer interface {
+	// ObserveRequest is called after sending each request with its method, URL
+	// path, response status code, or zero if no response is received, such as
+	// when the server is unreachable, and the duration until the response
+	// headers were received.
+	ObserveRequest(method, path string, status int, dur time.Duration)
+}
+
+// WithObserver returns an Option that calls the provided Observer after
+// sending each request to the mountebank server.
+func WithObserver(o Observer) Option {
+	return func(cli *Client) {
+		cli.restCli.AddObserver(o.ObserveRequest)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ogbofjnr/mbgo"
+	"github.com/ogbofjnr/mbgo/internal/assert"
+)
+
+func TestOptions_Header(t *testing.T) {
+	cases := map[string]struct {
+		opts []mbgo.Option
+		want http.Header
+	}{
+		"WithHeaders adds the headers to every request": {
+			opts: []mbgo.Option{
+				mbgo.WithHeaders(http.Header{
+					"X-Api-Key": []string{"abc123"},
+				}),
+			},
+			want: http.Header{
+				"X-Api-Key": []string{"abc123"},
+			},
+		},
+		"WithAccept replaces the default accept header": {
+			opts: []mbgo.Option{
+				mbgo.WithAccept("application/json; charset=utf-8"),
+			},
+			want: http.Header{
+				"Accept": []string{"application/json; charset=utf-8"},
+			},
+		},
+		"WithAccept omits the accept header if empty": {
+			opts: []mbgo.Option{
+				mbgo.WithAccept(""),
+			},
+			want: http.Header{
+				"Accept": nil,
+			},
+		},
+		"sends the default user agent header": {
+			want: http.Header{
+				"User-Agent": []string{"mbgo/" + mbgo.LibraryVersion},
+			},
+		},
+		"WithUserAgent replaces the default user agent header": {
+			opts: []mbgo.Option{
+				mbgo.WithUserAgent("integration-tests/1.0"),
+			},
+			want: http.Header{
+				"User-Agent": []string{"integration-tests/1.0"},
+			},
+		},
+		"WithUserAgent uses the default Go user agent header if empty": {
+			opts: []mbgo.Option{
+				mbgo.WithUserAgent(""),
+			},
+			want: http.Header{
+				"User-Agent": []string{"Go-http-client/1.1"},
+			},
+		},
+		"WithBasicAuth adds the basic authorization header to every request": {
+			opts: []mbgo.Option{
+				mbgo.WithBasicAuth("user", "pass"),
+			},
+			want: http.Header{
+				"Authorization": []string{"Basic dXNlcjpwYXNz"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make(http.Header)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k := range c.want {
+					got[k] = r.Header[k]
+				}
+			}))
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL)
+			assert.MustOk(t, err)
+
+			mb := mbgo.NewClient(srv.Client(), u, c.opts...)
+			err = mb.Ping(context.Background())
+			assert.MustOk(t, err)
+			assert.Equals(t, c.want, got)
+		})
+	}
+}
+
+func TestWithHeaderFunc(t *testing.T) {
+	t.Parallel()
+
+	type requestIDKey struct{}
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Request-Id")
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	assert.MustOk(t, err)
+
+	mb := mbgo.NewClient(srv.Client(), u, mbgo.WithHeaderFunc(func(ctx context.Context) http.Header {
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		return http.Header{
+			"X-Request-Id": []string{id},
+		}
+	}))
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "a1b2c3")
+	err = mb.Ping(ctx)
+	assert.MustOk(t, err)
+	assert.Equals(t, "a1b2c3", got)
+}
+
+// roundTripperFunc is an http.RoundTripper implemented by a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestWithRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, "server "+strings.Join(r.Header["X-Middleware"], ","))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	assert.MustOk(t, err)
+
+	middleware := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				got = append(got, name)
+				req = req.Clone(req.Context())
+				req.Header.Add("X-Middleware", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	hc := srv.Client()
+	transport := hc.Transport
+	mb := mbgo.NewClient(hc, u,
+		mbgo.WithRoundTripper(middleware("inner")),
+		mbgo.WithRoundTripper(middleware("outer")),
+	)
+
+	err = mb.Ping(context.Background())
+	assert.MustOk(t, err)
+	assert.Equals(t, []string{"outer", "inner", "server outer,inner"}, got)
+	assert.Equals(t, transport, hc.Transport)
+}
+
+// observedRequest is a request observed by a testObserver.
+type observedRequest struct {
+	Method string
+	Path   string
+	Status int
+}
+
+// testObserver records the requests it observes.
+type testObserver struct {
+	requests []observedRequest
+}
+
+// ObserveRequest satisfies the mbgo.Observer interface.
+func (o *testObserver) ObserveRequest(method, path string, status int, dur time.Duration) {
+	if dur < 0 {
+		panic("negative request duration")
+	}
+	o.requests = append(o.requests, observedRequest{Method: method, Path: path, Status: status})
+}
+
+func TestWithObserver(t *testing.T) {
+	cases := map[string]struct {
+		closed bool
+		want   []observedRequest
+	}{
+		"observes the requests and their response status code": {
+			want: []observedRequest{
+				{Method: http.MethodGet, Path: "/", Status: http.StatusOK},
+				{Method: http.MethodDelete, Path: "/imposters/8080", Status: http.StatusNotFound},
+			},
+		},
+		"observes the requests without a response with a zero status code": {
+			closed: true,
+			want: []observedRequest{
+				{Method: http.MethodGet, Path: "/", Status: 0},
+				{Method: http.MethodDelete, Path: "/imposters/8080", Status: 0},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/" {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+			if c.closed {
+				srv.Close()
+			}
+
+			u, err := url.Parse(srv.URL)
+			assert.MustOk(t, err)
+
+			o := &testObserver{}
+			mb := mbgo.NewClient(srv.Client(), u, mbgo.WithObserver(o))
+
+			_ = mb.Ping(context.Background())
+			_, _ = mb.Delete(context.Background(), 8080, false)
+			assert.Equals(t, c.want, o.requests)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo
+
+import (
+	"context"
+)
+
+// Tracer starts a Span for an operation of a Client, such as to surface the
+// latency of requests to the mountebank server in a distributed trace. It can
+// be implemented by a thin adapter over a tracing library, such as OpenTelemetry.
+// See WithTracer.
+type Tracer interface {
+	// Start starts a new Span for the named operation, such as "Create", as a
+	// child of any span in the provided context, returning a context containing
+	// the new Span. The attributes describe the operation, such as the "port"
+	// and "protocol" of an Imposter, and may be empty.
+	Start(ctx context.Context, operation string, attrs map[string]interface{}) (context.Context, Span)
+}
+
+// Span is a single traced operation of a Client started by a Tracer.
+type Span interface {
+	// End ends the Span, given the error returned by the operation, if any.
+	End(err error)
+}
+
+// WithTracer returns an Option that traces the imposter operations of the
+// Client using the provided Tracer, starting a Span named after each of the
+// Create, Imposter, Delete and DeleteAll methods when the method is called
+// and ending it when the method returns.
+func WithTracer(t Tracer) Option {
+	return func(cli *Client) {
+		cli.tracer = t
+	}
+}
+
+// noopSpan is a Span that does nothing, used if the Client has no Tracer.
+type noopSpan struct{}
+
+// End satisfies the Span interface.
+func (noopSpan) End(error) {}
+
+// startSpan starts a Span for the named operation using the Tracer of the
+// Client, or returns a Span that does nothing if the Client has no Tracer.
+func (cli *Client) startSpan(ctx context.Context, operation string, attrs map[string]interface{}) (context.Context, Span) {
+	if cli.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return cli.tracer.Start(ctx, operation, attrs)
+}
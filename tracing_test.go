@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Senseye Ltd. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in the LICENSE file.
+
+package mbgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ogbofjnr/mbgo"
+	"github.com/ogbofjnr/mbgo/internal/assert"
+)
+
+// testSpan records a started span and the error it is ended with.
+type testSpan struct {
+	Operation string
+	Attrs     map[string]interface{}
+	Ended     bool
+	Err       error
+}
+
+// End satisfies the mbgo.Span interface.
+func (s *testSpan) End(err error) {
+	s.Ended = true
+	s.Err = err
+}
+
+// testTracer records the spans it starts.
+type testTracer struct {
+	spans []*testSpan
+}
+
+// Start satisfies the mbgo.Tracer interface.
+func (t *testTracer) Start(ctx context.Context, operation string, attrs map[string]interface{}) (context.Context, mbgo.Span) {
+	s := &testSpan{Operation: operation, Attrs: attrs}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func TestWithTracer(t *testing.T) {
+	cases := map[string]struct {
+		status int
+		body   string
+		call   func(context.Context, *mbgo.Client) error
+		want   *testSpan
+	}{
+		"traces Create with the imposter port and protocol": {
+			status: http.StatusCreated,
+			body:   `{"protocol": "http", "port": 8080}`,
+			call: func(ctx context.Context, mb *mbgo.Client) error {
+				_, err := mb.Create(ctx, mbgo.Imposter{Port: 8080, Proto: "http"})
+				return err
+			},
+			want: &testSpan{
+				Operation: "Create",
+				Attrs:     map[string]interface{}{"port": 8080, "protocol": "http"},
+				Ended:     true,
+			},
+		},
+		"traces Imposter with the imposter port": {
+			status: http.StatusOK,
+			body:   `{"protocol": "http", "port": 8080}`,
+			call: func(ctx context.Context, mb *mbgo.Client) error {
+				_, err := mb.Imposter(ctx, 8080, false)
+				return err
+			},
+			want: &testSpan{
+				Operation: "Imposter",
+				Attrs:     map[string]interface{}{"port": 8080},
+				Ended:     true,
+			},
+		},
+		"traces Delete with the error returned": {
+			status: http.StatusInternalServerError,
+			body:   `{"errors": [{"code": "internal error", "message": "unavailable"}]}`,
+			call: func(ctx context.Context, mb *mbgo.Client) error {
+				_, err := mb.Delete(ctx, 8080, false)
+				return err
+			},
+			want: &testSpan{
+				Operation: "Delete",
+				Attrs:     map[string]interface{}{"port": 8080},
+				Ended:     true,
+				Err: &mbgo.APIError{
+					StatusCode: http.StatusInternalServerError,
+					Errors: []mbgo.ErrorDetail{
+						{Code: "internal error", Message: "unavailable"},
+					},
+				},
+			},
+		},
+		"traces DeleteAll": {
+			status: http.StatusOK,
+			body:   `{"imposters": []}`,
+			call: func(ctx context.Context, mb *mbgo.Client) error {
+				_, err := mb.DeleteAll(ctx, false)
+				return err
+			},
+			want: &testSpan{
+				Operation: "DeleteAll",
+				Ended:     true,
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.status)
+				_, _ = w.Write([]byte(c.body))
+			}))
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL)
+			assert.MustOk(t, err)
+
+			tracer := &testTracer{}
+			mb := mbgo.NewClient(srv.Client(), u, mbgo.WithTracer(tracer))
+
+			err = c.call(context.Background(), mb)
+			assert.Equals(t, c.want.Err, err)
+			assert.Equals(t, []*testSpan{c.want}, tracer.spans)
+		})
+	}
+}
+
+func TestWithTracer_Untraced(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	assert.MustOk(t, err)
+
+	tracer := &testTracer{}
+	mb := mbgo.NewClient(srv.Client(), u, mbgo.WithTracer(tracer))
+
+	err = mb.Ping(context.Background())
+	assert.MustOk(t, err)
+	assert.Equals(t, 0, len(tracer.spans))
+}